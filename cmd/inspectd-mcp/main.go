@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/Aldiwildan77/inspectd/internal/attach"
 	"github.com/Aldiwildan77/inspectd/sdk"
 	"github.com/Aldiwildan77/inspectd/sdk/storage"
 )
@@ -57,7 +58,7 @@ type MCPServer struct {
 func NewMCPServer() (*MCPServer, error) {
 	// Use bounded memory storage for MCP server
 	memStorage := storage.NewBoundedMemoryStorage(1000)
-	client := sdk.NewClient(memStorage)
+	client := sdk.NewClient(sdk.WithStorage(memStorage))
 
 	return &MCPServer{
 		client: client,
@@ -135,6 +136,13 @@ func (s *MCPServer) HandleRequest(req MCPRequest) MCPResponse {
 	return resp
 }
 
+// pidProperty describes the optional pid argument accepted by collection tools.
+// When set, data is read from the inspectd agent embedded in that process.
+var pidProperty = map[string]interface{}{
+	"type":        "integer",
+	"description": "PID of a process running the inspectd agent (default: the MCP server itself)",
+}
+
 // listTools returns available MCP tools
 func (s *MCPServer) listTools() []MCPTool {
 	return []MCPTool{
@@ -143,7 +151,9 @@ func (s *MCPServer) listTools() []MCPTool {
 			Description: "Collect a runtime snapshot from the current Go process",
 			InputSchema: map[string]interface{}{
 				"type": "object",
-				"properties": map[string]interface{}{},
+				"properties": map[string]interface{}{
+					"pid": pidProperty,
+				},
 			},
 		},
 		{
@@ -172,7 +182,9 @@ func (s *MCPServer) listTools() []MCPTool {
 			Description: "Get Go runtime information (version, goroutines, CPU, uptime)",
 			InputSchema: map[string]interface{}{
 				"type": "object",
-				"properties": map[string]interface{}{},
+				"properties": map[string]interface{}{
+					"pid": pidProperty,
+				},
 			},
 		},
 		{
@@ -180,7 +192,9 @@ func (s *MCPServer) listTools() []MCPTool {
 			Description: "Get memory usage and GC statistics",
 			InputSchema: map[string]interface{}{
 				"type": "object",
-				"properties": map[string]interface{}{},
+				"properties": map[string]interface{}{
+					"pid": pidProperty,
+				},
 			},
 		},
 		{
@@ -188,7 +202,9 @@ func (s *MCPServer) listTools() []MCPTool {
 			Description: "Get the current goroutine count",
 			InputSchema: map[string]interface{}{
 				"type": "object",
-				"properties": map[string]interface{}{},
+				"properties": map[string]interface{}{
+					"pid": pidProperty,
+				},
 			},
 		},
 	}
@@ -200,6 +216,9 @@ func (s *MCPServer) callTool(name string, args map[string]interface{}) (interfac
 
 	switch name {
 	case "collect_snapshot":
+		if pid := pidArg(args); pid != 0 {
+			return attach.Call(ctx, pid, "snapshot", nil)
+		}
 		snapshot, err := s.client.CollectSnapshot()
 		if err != nil {
 			return nil, err
@@ -224,6 +243,9 @@ func (s *MCPServer) callTool(name string, args map[string]interface{}) (interfac
 		return map[string]interface{}{"snapshots": snapshots}, nil
 
 	case "get_runtime_info":
+		if pid := pidArg(args); pid != 0 {
+			return attach.Call(ctx, pid, "runtime", nil)
+		}
 		snapshot, err := s.client.CollectSnapshot()
 		if err != nil {
 			return nil, err
@@ -231,6 +253,9 @@ func (s *MCPServer) callTool(name string, args map[string]interface{}) (interfac
 		return snapshot.Runtime, nil
 
 	case "get_memory_info":
+		if pid := pidArg(args); pid != 0 {
+			return attach.Call(ctx, pid, "memory", nil)
+		}
 		snapshot, err := s.client.CollectSnapshot()
 		if err != nil {
 			return nil, err
@@ -238,6 +263,9 @@ func (s *MCPServer) callTool(name string, args map[string]interface{}) (interfac
 		return snapshot.Memory, nil
 
 	case "get_goroutine_count":
+		if pid := pidArg(args); pid != 0 {
+			return attach.Call(ctx, pid, "goroutines", nil)
+		}
 		snapshot, err := s.client.CollectSnapshot()
 		if err != nil {
 			return nil, err
@@ -249,6 +277,14 @@ func (s *MCPServer) callTool(name string, args map[string]interface{}) (interfac
 	}
 }
 
+// pidArg returns the pid tool argument, or 0 when the current process should be inspected.
+func pidArg(args map[string]interface{}) int {
+	if p, ok := args["pid"].(float64); ok {
+		return int(p)
+	}
+	return 0
+}
+
 // listResources returns available MCP resources
 func (s *MCPServer) listResources() []MCPResource {
 	return []MCPResource{
@@ -2,8 +2,13 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
+	"os/signal"
 	"runtime"
 	"time"
+
+	"github.com/Aldiwildan77/inspectd/sdk/agent"
 )
 
 func main() {
@@ -12,6 +17,13 @@ func main() {
 	fmt.Println("Run inspectd commands in another terminal to inspect this process")
 	fmt.Println()
 
+	// Expose this process to `inspectd --pid <pid> <command>`
+	a, err := agent.Start()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer a.Close()
+
 	// Create some goroutines
 	for i := 0; i < 5; i++ {
 		go func(id int) {
@@ -29,10 +41,13 @@ func main() {
 		data = append(data, buf)
 	}
 
+	fmt.Printf("PID: %d (agent socket: %s)\n", os.Getpid(), a.SocketPath())
 	fmt.Printf("Created %d goroutines\n", runtime.NumGoroutine())
 	fmt.Printf("Allocated ~%d MB\n", len(data))
 	fmt.Println("Press Ctrl+C to exit")
 
-	// Keep running
-	select {}
+	// Keep running until interrupted so the agent can remove its socket
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	<-sig
 }
@@ -16,7 +16,7 @@ func main() {
 	memStorage := storage.NewMemoryStorage()
 	defer memStorage.Close()
 
-	client := sdk.NewClient(memStorage)
+	client := sdk.NewClient(sdk.WithStorage(memStorage))
 
 	// Collect and store a snapshot
 	ctx := context.Background()
@@ -45,7 +45,7 @@ func main() {
 	}
 	defer fileStorage.Close()
 
-	fileClient := sdk.NewClient(fileStorage)
+	fileClient := sdk.NewClient(sdk.WithStorage(fileStorage))
 
 	// Collect and store multiple snapshots
 	for i := 0; i < 3; i++ {
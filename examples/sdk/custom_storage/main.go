@@ -70,7 +70,7 @@ func main() {
 	defer customStorage.Close()
 
 	// Create SDK client with custom storage
-	client := sdk.NewClient(customStorage)
+	client := sdk.NewClient(sdk.WithStorage(customStorage))
 
 	// Collect and store snapshots
 	ctx := context.Background()
@@ -25,7 +25,7 @@ func main() {
 	defer memStorage.Close()
 
 	// Create SDK client
-	client := sdk.NewClient(memStorage)
+	client := sdk.NewClient(sdk.WithStorage(memStorage))
 	defer client.Close()
 
 	// Context with timeout for operations
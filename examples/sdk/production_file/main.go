@@ -34,7 +34,7 @@ func main() {
 	defer fileStorage.Close()
 
 	// Create SDK client
-	client := sdk.NewClient(fileStorage)
+	client := sdk.NewClient(sdk.WithStorage(fileStorage))
 	defer client.Close()
 
 	// Context with timeout
@@ -0,0 +1,211 @@
+package attach
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Request is sent by the CLI to the agent embedded in a target process.
+// Command and Args mirror the CLI invocation, e.g. "snapshot" with no args.
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// Response carries either the command's JSON output or an error message.
+// Code classifies the error when the command provides one (e.g., "unknown_command").
+type Response struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Code  string          `json:"code,omitempty"`
+}
+
+// RemoteError is a command that ran in the target process and failed there,
+// as opposed to a failure to reach the agent.
+type RemoteError struct {
+	PID     int
+	Code    string
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return fmt.Sprintf("pid %d: %s", e.PID, e.Message)
+}
+
+// socketDirEnv overrides the directory agents create their sockets in.
+const socketDirEnv = "INSPECTD_SOCKET_DIR"
+
+// SocketDir returns the directory holding the current user's per-PID agent sockets.
+// INSPECTD_SOCKET_DIR overrides the default of $TMPDIR/inspectd-<uid>. Each user
+// has their own directory, so one user's agents cannot lock out another's.
+func SocketDir() string {
+	if dir := os.Getenv(socketDirEnv); dir != "" {
+		return dir
+	}
+	return userSocketDir(os.Getuid())
+}
+
+func userSocketDir(uid int) string {
+	if uid < 0 {
+		// No user IDs on this platform (Windows).
+		return filepath.Join(os.TempDir(), "inspectd")
+	}
+	return filepath.Join(os.TempDir(), "inspectd-"+strconv.Itoa(uid))
+}
+
+// MakeSocketDir creates dir for an agent's socket if needed and checks that it
+// belongs to the current user and is closed to everyone else, since whoever can
+// reach into it can connect to or replace the sockets in it.
+func MakeSocketDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check socket directory: %w", err)
+	}
+	uid, ok := owner(info)
+	if !ok {
+		return nil
+	}
+	if uid != os.Getuid() {
+		return fmt.Errorf("socket directory %s is owned by uid %d, not the current user (uid %d); set %s to a directory you own", dir, uid, os.Getuid(), socketDirEnv)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("socket directory %s has mode %o, want no access for group or others; run chmod 700 on it", dir, perm)
+	}
+	return nil
+}
+
+// socketDirs lists the directories searched for agent sockets: SocketDir, then the
+// default directories of other users, which only root can usually read. Those are
+// skipped unless owned by the user they are named after.
+func socketDirs() []string {
+	dirs := []string{SocketDir()}
+	if os.Getenv(socketDirEnv) != "" {
+		return dirs
+	}
+	matches, _ := filepath.Glob(filepath.Join(os.TempDir(), "inspectd-*"))
+	for _, dir := range matches {
+		uid, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "inspectd-"))
+		if err != nil || dir == dirs[0] {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			if owner, ok := owner(info); ok && owner == uid {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// SocketPath returns the agent socket path for the given PID, looking in the
+// current user's directory first and then in those of other users.
+func SocketPath(pid int) string {
+	name := strconv.Itoa(pid) + ".sock"
+	dirs := socketDirs()
+	for _, dir := range dirs {
+		path := filepath.Join(dir, name)
+		if _, err := os.Lstat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dirs[0], name)
+}
+
+// Call runs a command inside the process identified by pid and returns its JSON output.
+func Call(ctx context.Context, pid int, command string, args []string) (json.RawMessage, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", SocketPath(pid))
+	if err != nil {
+		return nil, fmt.Errorf("no inspectd agent reachable for pid %d: %w", pid, err)
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := json.NewEncoder(conn).Encode(Request{Command: command, Args: args}); err != nil {
+		return nil, fmt.Errorf("failed to send request to pid %d: %w", pid, err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to read response from pid %d: %w", pid, err)
+	}
+	if resp.Error != "" {
+		return nil, &RemoteError{PID: pid, Code: resp.Code, Message: resp.Error}
+	}
+
+	return resp.Data, nil
+}
+
+// ServeConn answers a single request on conn using run and closes the connection.
+// ctx is passed to run, so cancelling it stops long captures early.
+func ServeConn(ctx context.Context, conn net.Conn, run func(ctx context.Context, name string, args []string) ([]byte, error)) {
+	defer conn.Close()
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	var resp Response
+	data, err := run(ctx, req.Command, req.Args)
+	if err != nil {
+		resp.Error = err.Error()
+		var coded interface{ ErrorCode() string }
+		if errors.As(err, &coded) {
+			resp.Code = coded.ErrorCode()
+		}
+	} else {
+		resp.Data = data
+	}
+
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// ListPIDs returns the PIDs that have a socket in a directory SocketPath searches,
+// in ascending order. A socket may be stale if its process exited without closing the agent.
+func ListPIDs() ([]int, error) {
+	seen := make(map[int]bool)
+	pids := make([]int, 0)
+	for i, dir := range socketDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			// Other users' directories are expected to be unreadable.
+			if i > 0 || errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read socket directory: %w", err)
+		}
+
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), ".sock")
+			if !ok || entry.IsDir() {
+				continue
+			}
+			pid, err := strconv.Atoi(name)
+			if err != nil || pid <= 0 || seen[pid] {
+				continue
+			}
+			seen[pid] = true
+			pids = append(pids, pid)
+		}
+	}
+
+	sort.Ints(pids)
+	return pids, nil
+}
@@ -0,0 +1,113 @@
+package attach
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSocketDirPerUser(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv(socketDirEnv, "")
+
+	if os.Getuid() >= 0 {
+		if got, want := SocketDir(), filepath.Join(tmp, "inspectd-"+strconv.Itoa(os.Getuid())); got != want {
+			t.Errorf("SocketDir() = %s, want %s", got, want)
+		}
+	}
+
+	t.Setenv(socketDirEnv, "/run/inspectd")
+	if got := SocketDir(); got != "/run/inspectd" {
+		t.Errorf("SocketDir() = %s, want the %s override", got, socketDirEnv)
+	}
+}
+
+// Root reaches agents started by other users, but only through directories
+// owned by the user they are named after.
+func TestSocketPathOtherUsers(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("creating other users' directories requires root")
+	}
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv(socketDirEnv, "")
+
+	socket := func(dir string, uid, pid int) string {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chown(dir, uid, uid); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, strconv.Itoa(pid)+".sock")
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	own := socket(filepath.Join(tmp, "inspectd-0"), 0, 100)
+	other := socket(filepath.Join(tmp, "inspectd-1000"), 1000, 200)
+	socket(filepath.Join(tmp, "inspectd-2000"), 1000, 300) // Not owned by uid 2000
+
+	tests := []struct {
+		pid  int
+		want string
+	}{
+		{100, own},
+		{200, other},
+		{300, filepath.Join(tmp, "inspectd-0", "300.sock")},
+		{400, filepath.Join(tmp, "inspectd-0", "400.sock")},
+	}
+	for _, tt := range tests {
+		if got := SocketPath(tt.pid); got != tt.want {
+			t.Errorf("SocketPath(%d) = %s, want %s", tt.pid, got, tt.want)
+		}
+	}
+
+	pids, err := ListPIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{100, 200}; !slices.Equal(pids, want) {
+		t.Errorf("ListPIDs() = %v, want %v", pids, want)
+	}
+}
+
+func TestListPIDsNoDirectory(t *testing.T) {
+	t.Setenv(socketDirEnv, filepath.Join(t.TempDir(), "missing"))
+	pids, err := ListPIDs()
+	if err != nil || len(pids) != 0 {
+		t.Errorf("ListPIDs() = %v, %v, want no PIDs", pids, err)
+	}
+}
+
+func TestMakeSocketDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	if err := MakeSocketDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("socket directory mode = %o, want 700", perm)
+	}
+}
+
+func TestMakeSocketDirRejectsOpenDirectory(t *testing.T) {
+	if os.Getuid() < 0 {
+		t.Skip("directory modes are not checked on this platform")
+	}
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := MakeSocketDir(dir); err == nil || !strings.Contains(err.Error(), "mode 755") {
+		t.Errorf("MakeSocketDir() error = %v, want the open mode reported", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package attach
+
+import "os"
+
+// owner is unknown where files have no Unix owner; socket directories are not checked.
+func owner(os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package attach
+
+import (
+	"os"
+	"syscall"
+)
+
+// owner returns the user ID that owns the file.
+func owner(info os.FileInfo) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
@@ -0,0 +1,100 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime/debug"
+	"strings"
+)
+
+type BuildInfo struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Main      Module            `json:"main"`
+	VCS       *VCS              `json:"vcs,omitempty"`
+	Settings  map[string]string `json:"settings"`
+	Deps      []Module          `json:"deps"`
+}
+
+type Module struct {
+	Path    string  `json:"path"`
+	Version string  `json:"version"`
+	Sum     string  `json:"sum,omitempty"`
+	Replace *Module `json:"replace,omitempty"`
+}
+
+type VCS struct {
+	System   string `json:"system"`
+	Revision string `json:"revision"`
+	Time     string `json:"time"`
+	Modified bool   `json:"modified"`
+}
+
+// Collect reads the build information embedded in the binary. It returns nil
+// when the binary was built without module support.
+func Collect() (*BuildInfo, error) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, nil
+	}
+
+	info := &BuildInfo{
+		GoVersion: bi.GoVersion,
+		Path:      bi.Path,
+		Main:      convertModule(&bi.Main),
+		Settings:  make(map[string]string),
+		Deps:      make([]Module, 0, len(bi.Deps)),
+	}
+
+	// vcs.* settings are only present when built from a VCS checkout.
+	for _, setting := range bi.Settings {
+		vcsKey, isVCS := strings.CutPrefix(setting.Key, "vcs")
+		if !isVCS {
+			info.Settings[setting.Key] = setting.Value
+			continue
+		}
+		if info.VCS == nil {
+			info.VCS = &VCS{}
+		}
+		switch vcsKey {
+		case "":
+			info.VCS.System = setting.Value
+		case ".revision":
+			info.VCS.Revision = setting.Value
+		case ".time":
+			info.VCS.Time = setting.Value
+		case ".modified":
+			info.VCS.Modified = setting.Value == "true"
+		}
+	}
+
+	for _, dep := range bi.Deps {
+		info.Deps = append(info.Deps, convertModule(dep))
+	}
+
+	return info, nil
+}
+
+func CollectJSON() ([]byte, error) {
+	info, err := Collect()
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, errors.New("build information not available (binary built without module support)")
+	}
+	return json.Marshal(info)
+}
+
+func convertModule(m *debug.Module) Module {
+	module := Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Sum:     m.Sum,
+	}
+	if m.Replace != nil {
+		replace := convertModule(m.Replace)
+		module.Replace = &replace
+	}
+	return module
+}
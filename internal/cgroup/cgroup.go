@@ -0,0 +1,258 @@
+package cgroup
+
+import (
+	"bufio"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CgroupInfo describes the CPU and memory limits of the cgroup the process runs in.
+// Limits are null when the cgroup does not set them.
+type CgroupInfo struct {
+	Version                int      `json:"version"`
+	CPUQuotaCores          *float64 `json:"cpu_quota_cores"`
+	CPUQuotaMicros         int64    `json:"cpu_quota_us"`
+	CPUPeriodMicros        uint64   `json:"cpu_period_us"`
+	CPUUsageSeconds        float64  `json:"cpu_usage_seconds"`
+	Periods                uint64   `json:"nr_periods"`
+	ThrottledPeriods       uint64   `json:"nr_throttled"`
+	ThrottledSeconds       float64  `json:"throttled_seconds"`
+	MemoryLimit            *uint64  `json:"memory_limit_bytes"`
+	MemoryUsage            uint64   `json:"memory_usage_bytes"`
+	GOMAXPROCSExceedsQuota bool     `json:"gomaxprocs_exceeds_quota"`
+}
+
+var (
+	procSelfCgroup = "/proc/self/cgroup"
+	cgroupRoot     = "/sys/fs/cgroup"
+)
+
+// v1 reports "unlimited" memory as a page-aligned value near MaxInt64.
+const v1UnlimitedMemory = 1 << 62
+
+// Collect reads the cgroup v1 or v2 files for the current process. It returns nil
+// when the process is not in a cgroup or no cgroup filesystem is mounted (e.g., on
+// non-Linux systems). Limits are the tightest set by the process's cgroup or any
+// of its ancestors. gomaxprocs is compared against the CPU quota.
+func Collect(gomaxprocs int) (*CgroupInfo, error) {
+	paths, err := readProcCgroup(procSelfCgroup)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var info *CgroupInfo
+	switch {
+	case exists(filepath.Join(cgroupRoot, "cgroup.controllers")):
+		info = collectV2(cgroupRoot, resolve(cgroupRoot, paths[""]))
+	case isDir(filepath.Join(cgroupRoot, "cpu")) || isDir(filepath.Join(cgroupRoot, "memory")):
+		info = collectV1(
+			hierarchyOf(filepath.Join(cgroupRoot, "cpu"), paths["cpu"]),
+			hierarchyOf(filepath.Join(cgroupRoot, "cpuacct"), paths["cpuacct"]),
+			hierarchyOf(filepath.Join(cgroupRoot, "memory"), paths["memory"]),
+		)
+	default:
+		return nil, nil
+	}
+
+	if info.CPUQuotaCores != nil && float64(gomaxprocs) > math.Ceil(*info.CPUQuotaCores) {
+		info.GOMAXPROCSExceedsQuota = true
+	}
+
+	return info, nil
+}
+
+// hierarchy is where a v1 controller is mounted and the process's cgroup
+// directory within it.
+type hierarchy struct {
+	mount, dir string
+}
+
+func hierarchyOf(mount, cgroupPath string) hierarchy {
+	return hierarchy{mount: mount, dir: resolve(mount, cgroupPath)}
+}
+
+func collectV2(mount, dir string) *CgroupInfo {
+	info := &CgroupInfo{Version: 2, CPUQuotaMicros: -1}
+
+	// Limits apply hierarchically, so a parent can be tighter than the leaf.
+	for _, d := range ancestors(mount, dir) {
+		// cpu.max is "<quota|max> <period>"
+		if fields := strings.Fields(readString(filepath.Join(d, "cpu.max"))); len(fields) == 2 {
+			quota, err := strconv.ParseInt(fields[0], 10, 64)
+			period, _ := strconv.ParseUint(fields[1], 10, 64)
+			if info.CPUPeriodMicros == 0 {
+				info.CPUPeriodMicros = period // The leaf's period when no quota applies
+			}
+			if err == nil {
+				info.tightenCPU(quota, period)
+			}
+		}
+		if limit, err := strconv.ParseUint(readString(filepath.Join(d, "memory.max")), 10, 64); err == nil {
+			info.tightenMemory(limit)
+		}
+	}
+
+	stat := readKeyValues(filepath.Join(dir, "cpu.stat"))
+	info.CPUUsageSeconds = float64(stat["usage_usec"]) / 1e6
+	info.Periods = stat["nr_periods"]
+	info.ThrottledPeriods = stat["nr_throttled"]
+	info.ThrottledSeconds = float64(stat["throttled_usec"]) / 1e6
+
+	info.MemoryUsage, _ = strconv.ParseUint(readString(filepath.Join(dir, "memory.current")), 10, 64)
+
+	return info
+}
+
+func collectV1(cpu, cpuacct, memory hierarchy) *CgroupInfo {
+	info := &CgroupInfo{Version: 1, CPUQuotaMicros: -1}
+
+	for _, d := range ancestors(cpu.mount, cpu.dir) {
+		quota, err := strconv.ParseInt(readString(filepath.Join(d, "cpu.cfs_quota_us")), 10, 64)
+		period, _ := strconv.ParseUint(readString(filepath.Join(d, "cpu.cfs_period_us")), 10, 64)
+		if info.CPUPeriodMicros == 0 {
+			info.CPUPeriodMicros = period // The leaf's period when no quota applies
+		}
+		if err == nil {
+			info.tightenCPU(quota, period)
+		}
+	}
+	for _, d := range ancestors(memory.mount, memory.dir) {
+		if limit, err := strconv.ParseUint(readString(filepath.Join(d, "memory.limit_in_bytes")), 10, 64); err == nil && limit < v1UnlimitedMemory {
+			info.tightenMemory(limit)
+		}
+	}
+
+	stat := readKeyValues(filepath.Join(cpu.dir, "cpu.stat"))
+	info.Periods = stat["nr_periods"]
+	info.ThrottledPeriods = stat["nr_throttled"]
+	info.ThrottledSeconds = float64(stat["throttled_time"]) / 1e9
+
+	if usage, err := strconv.ParseUint(readString(filepath.Join(cpuacct.dir, "cpuacct.usage")), 10, 64); err == nil {
+		info.CPUUsageSeconds = float64(usage) / 1e9
+	}
+
+	info.MemoryUsage, _ = strconv.ParseUint(readString(filepath.Join(memory.dir, "memory.usage_in_bytes")), 10, 64)
+
+	return info
+}
+
+// tightenCPU records the quota if it allows fewer cores than the one seen so far.
+// Unlimited quotas (max or -1) are ignored.
+func (info *CgroupInfo) tightenCPU(quota int64, period uint64) {
+	cores := quotaCores(quota, period)
+	if cores == nil || (info.CPUQuotaCores != nil && *info.CPUQuotaCores <= *cores) {
+		return
+	}
+	info.CPUQuotaMicros, info.CPUPeriodMicros, info.CPUQuotaCores = quota, period, cores
+}
+
+func (info *CgroupInfo) tightenMemory(limit uint64) {
+	if info.MemoryLimit == nil || limit < *info.MemoryLimit {
+		info.MemoryLimit = &limit
+	}
+}
+
+func quotaCores(quota int64, period uint64) *float64 {
+	if quota <= 0 || period == 0 {
+		return nil
+	}
+	cores := float64(quota) / float64(period)
+	return &cores
+}
+
+// readProcCgroup maps each controller to its cgroup path. The v2 unified
+// hierarchy ("0::/path") is stored under the empty controller name.
+func readProcCgroup(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			paths[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			paths[controller] = parts[2]
+		}
+	}
+	return paths, nil
+}
+
+// resolve joins a cgroup path to its mount point. Inside a container the path in
+// /proc/self/cgroup often refers to the host hierarchy, while the container only
+// sees its own cgroup mounted at the root, so fall back to the mount point itself.
+func resolve(mount, cgroupPath string) string {
+	if cgroupPath != "" {
+		dir := filepath.Join(mount, cgroupPath)
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+	return mount
+}
+
+// ancestors lists dir and each parent directory up to and including mount.
+func ancestors(mount, dir string) []string {
+	mount, dir = filepath.Clean(mount), filepath.Clean(dir)
+	dirs := []string{dir}
+	for dir != mount && strings.HasPrefix(dir, mount+string(filepath.Separator)) {
+		dir = filepath.Dir(dir)
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+func readString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readKeyValues parses files of "<key> <value>" lines such as cpu.stat.
+func readKeyValues(path string) map[string]uint64 {
+	values := make(map[string]uint64)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,222 @@
+package cgroup
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fakeCgroup points Collect at a temporary cgroup filesystem built from files,
+// keyed by path relative to the mount root.
+func fakeCgroup(t *testing.T, procCgroup string, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	root := filepath.Join(dir, "cgroup")
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	proc := filepath.Join(dir, "cgroup.proc")
+	if err := os.WriteFile(proc, []byte(procCgroup), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldProc, oldRoot := procSelfCgroup, cgroupRoot
+	procSelfCgroup, cgroupRoot = proc, root
+	t.Cleanup(func() { procSelfCgroup, cgroupRoot = oldProc, oldRoot })
+}
+
+func TestCollectV2Ancestors(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantCores  float64
+		wantQuota  int64
+		wantPeriod uint64
+		wantMemory uint64
+	}{
+		{
+			name: "parent tighter than leaf",
+			files: map[string]string{
+				"cgroup.controllers":              "cpu memory",
+				"kubepods/cpu.max":                "100000 100000",
+				"kubepods/memory.max":             "1073741824",
+				"kubepods/pod/cpu.max":            "max 100000",
+				"kubepods/pod/memory.max":         "max",
+				"kubepods/pod/app/cpu.max":        "400000 100000",
+				"kubepods/pod/app/memory.max":     "2147483648",
+				"kubepods/pod/app/cpu.stat":       "usage_usec 1500000\nnr_periods 10\nnr_throttled 2\nthrottled_usec 250000\n",
+				"kubepods/pod/app/memory.current": "1048576",
+			},
+			wantCores: 1, wantQuota: 100000, wantPeriod: 100000, wantMemory: 1073741824,
+		},
+		{
+			name: "leaf tighter than parent",
+			files: map[string]string{
+				"cgroup.controllers":          "cpu memory",
+				"kubepods/cpu.max":            "800000 100000",
+				"kubepods/memory.max":         "4294967296",
+				"kubepods/pod/app/cpu.max":    "50000 100000",
+				"kubepods/pod/app/memory.max": "536870912",
+			},
+			wantCores: 0.5, wantQuota: 50000, wantPeriod: 100000, wantMemory: 536870912,
+		},
+		{
+			name: "compares cores across periods",
+			files: map[string]string{
+				"cgroup.controllers":       "cpu memory",
+				"kubepods/cpu.max":         "150000 50000",
+				"kubepods/memory.max":      "max",
+				"kubepods/pod/app/cpu.max": "200000 100000",
+			},
+			wantCores: 2, wantQuota: 200000, wantPeriod: 100000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCgroup(t, "0::/kubepods/pod/app\n", tt.files)
+
+			info, err := Collect(4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info == nil || info.Version != 2 {
+				t.Fatalf("Collect() = %+v, want a v2 cgroup", info)
+			}
+			if info.CPUQuotaCores == nil || *info.CPUQuotaCores != tt.wantCores {
+				t.Errorf("CPUQuotaCores = %v, want %v", info.CPUQuotaCores, tt.wantCores)
+			}
+			if info.CPUQuotaMicros != tt.wantQuota || info.CPUPeriodMicros != tt.wantPeriod {
+				t.Errorf("quota/period = %d/%d, want %d/%d", info.CPUQuotaMicros, info.CPUPeriodMicros, tt.wantQuota, tt.wantPeriod)
+			}
+			if tt.wantMemory == 0 {
+				if info.MemoryLimit != nil {
+					t.Errorf("MemoryLimit = %d, want nil", *info.MemoryLimit)
+				}
+			} else if info.MemoryLimit == nil || *info.MemoryLimit != tt.wantMemory {
+				t.Errorf("MemoryLimit = %v, want %d", info.MemoryLimit, tt.wantMemory)
+			}
+			if want := tt.wantCores < 4; info.GOMAXPROCSExceedsQuota != want {
+				t.Errorf("GOMAXPROCSExceedsQuota = %v, want %v", info.GOMAXPROCSExceedsQuota, want)
+			}
+		})
+	}
+}
+
+func TestCollectV2Usage(t *testing.T) {
+	fakeCgroup(t, "0::/app\n", map[string]string{
+		"cgroup.controllers": "cpu memory",
+		"cpu.stat":           "usage_usec 9000000\n",
+		"memory.current":     "9999",
+		"app/cpu.stat":       "usage_usec 1500000\nnr_periods 10\nnr_throttled 2\nthrottled_usec 250000\n",
+		"app/memory.current": "1048576",
+	})
+
+	info, err := Collect(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Usage comes from the process's own cgroup, not its ancestors.
+	if info.CPUUsageSeconds != 1.5 || info.Periods != 10 || info.ThrottledPeriods != 2 || info.ThrottledSeconds != 0.25 {
+		t.Errorf("cpu usage = %+v", info)
+	}
+	if info.MemoryUsage != 1048576 {
+		t.Errorf("MemoryUsage = %d, want 1048576", info.MemoryUsage)
+	}
+	if info.CPUQuotaCores != nil || info.MemoryLimit != nil {
+		t.Errorf("limits = %v, %v, want none", info.CPUQuotaCores, info.MemoryLimit)
+	}
+}
+
+func TestCollectV1Ancestors(t *testing.T) {
+	fakeCgroup(t, "4:memory:/docker/abc\n3:cpu,cpuacct:/docker/abc\n", map[string]string{
+		"cpu/docker/cpu.cfs_quota_us":             "150000",
+		"cpu/docker/cpu.cfs_period_us":            "100000",
+		"cpu/docker/abc/cpu.cfs_quota_us":         "-1",
+		"cpu/docker/abc/cpu.cfs_period_us":        "100000",
+		"cpu/docker/abc/cpu.stat":                 "nr_periods 5\nnr_throttled 1\nthrottled_time 500000000\n",
+		"cpuacct/docker/abc/cpuacct.usage":        "3000000000",
+		"memory/memory.limit_in_bytes":            "9223372036854771712",
+		"memory/docker/memory.limit_in_bytes":     "268435456",
+		"memory/docker/abc/memory.limit_in_bytes": "536870912",
+		"memory/docker/abc/memory.usage_in_bytes": "4096",
+	})
+
+	info, err := Collect(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info == nil || info.Version != 1 {
+		t.Fatalf("Collect() = %+v, want a v1 cgroup", info)
+	}
+	if info.CPUQuotaCores == nil || *info.CPUQuotaCores != 1.5 || info.CPUQuotaMicros != 150000 {
+		t.Errorf("CPU quota = %v (%d us), want 1.5 cores from the parent", info.CPUQuotaCores, info.CPUQuotaMicros)
+	}
+	if info.MemoryLimit == nil || *info.MemoryLimit != 268435456 {
+		t.Errorf("MemoryLimit = %v, want 268435456 from the parent", info.MemoryLimit)
+	}
+	if info.CPUUsageSeconds != 3 || info.ThrottledSeconds != 0.5 || info.MemoryUsage != 4096 {
+		t.Errorf("usage = %+v", info)
+	}
+	if !info.GOMAXPROCSExceedsQuota {
+		t.Error("GOMAXPROCSExceedsQuota = false, want true")
+	}
+}
+
+func TestCollectNoCgroup(t *testing.T) {
+	tests := []struct {
+		name       string
+		procCgroup string
+		files      map[string]string
+	}{
+		{"nothing mounted", "0::/\n", nil},
+		{"unrelated mount contents", "0::/app\n", map[string]string{"README": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeCgroup(t, tt.procCgroup, tt.files)
+			info, err := Collect(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info != nil {
+				t.Errorf("Collect() = %+v, want nil", info)
+			}
+		})
+	}
+}
+
+func TestCollectNoProcCgroup(t *testing.T) {
+	old := procSelfCgroup
+	procSelfCgroup = filepath.Join(t.TempDir(), "missing")
+	t.Cleanup(func() { procSelfCgroup = old })
+
+	info, err := Collect(1)
+	if err != nil || info != nil {
+		t.Errorf("Collect() = %+v, %v, want nil, nil", info, err)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	tests := []struct {
+		mount, dir string
+		want       []string
+	}{
+		{"/sys/fs/cgroup", "/sys/fs/cgroup/a/b", []string{"/sys/fs/cgroup/a/b", "/sys/fs/cgroup/a", "/sys/fs/cgroup"}},
+		{"/sys/fs/cgroup", "/sys/fs/cgroup", []string{"/sys/fs/cgroup"}},
+		{"/sys/fs/cgroup/", "/sys/fs/cgroup/a/", []string{"/sys/fs/cgroup/a", "/sys/fs/cgroup"}},
+		// A sibling mount sharing a prefix is not an ancestor.
+		{"/sys/fs/cgroup/cpu", "/sys/fs/cgroup/cpuacct/a", []string{"/sys/fs/cgroup/cpuacct/a"}},
+	}
+	for _, tt := range tests {
+		if got := ancestors(tt.mount, tt.dir); !slices.Equal(got, tt.want) {
+			t.Errorf("ancestors(%q, %q) = %q, want %q", tt.mount, tt.dir, got, tt.want)
+		}
+	}
+}
@@ -1,41 +1,197 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
-	"github.com/Aldiwildan77/inspectd/internal/goroutines"
-	"github.com/Aldiwildan77/inspectd/internal/memory"
-	"github.com/Aldiwildan77/inspectd/internal/runtimeinfo"
-	"github.com/Aldiwildan77/inspectd/internal/snapshot"
+	"github.com/Aldiwildan77/inspectd/internal/command"
+	"github.com/Aldiwildan77/inspectd/internal/exectrace"
+	"github.com/Aldiwildan77/inspectd/internal/format"
+	"github.com/Aldiwildan77/inspectd/internal/osinfo"
+	"github.com/Aldiwildan77/inspectd/internal/process"
+	"github.com/Aldiwildan77/inspectd/internal/schema"
 )
 
 func Run() {
-	if len(os.Args) < 2 {
-		os.Exit(1)
+	fs, pid, outputFormat := newGlobalFlagSet()
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		fail(usageError(err))
 	}
 
-	command := os.Args[1]
+	if fs.NArg() < 1 {
+		fail(usageError(errors.New("missing command")))
+	}
+
+	name := fs.Arg(0)
+	args := fs.Args()[1:]
+
+	// --format is also accepted after the command, as it applies to every command.
+	value, rest, err := extractFlag(args, "format")
+	if err != nil {
+		fail(err)
+	}
+	if value != "" {
+		*outputFormat, args = value, rest
+	}
+	if !format.Valid(*outputFormat) {
+		fail(usageError(fmt.Errorf("unknown format: %s (expected one of: %s)", *outputFormat, strings.Join(format.Names(), ", "))))
+	}
+
+	// Prometheus metric names include the profile type.
+	metricPrefix := name
+	if name == "profile" && len(args) > 0 {
+		metricPrefix += " " + args[0]
+	}
+
+	var outputPath string
+	if name == "profile" {
+		if outputPath, args, err = extractOutputFlag(args); err != nil {
+			fail(err)
+		}
+	}
 
 	var output []byte
-	var err error
 
-	switch command {
-	case "runtime":
-		output, err = runtimeinfo.CollectJSON()
-	case "memory":
-		output, err = memory.CollectJSON()
-	case "goroutines":
-		output, err = goroutines.CollectJSON()
-	case "snapshot":
-		output, err = snapshot.CollectJSON()
-	default:
-		os.Exit(1)
+	ctx := context.Background()
+	if cmd := findLocal(localCommands(*pid), name); cmd != nil {
+		output, err = cmd.Run(ctx, args)
+	} else if name == "os" && *pid != 0 {
+		// /proc/<pid> is readable without an agent in the target.
+		output, err = osinfo.CollectPIDJSON(*pid)
+	} else if *pid != 0 {
+		output, err = callAgent(ctx, *pid, name, args)
+	} else {
+		output, err = command.Run(ctx, name, args)
+	}
+
+	if err == nil && outputPath != "" {
+		output, err = writeRawProfile(output, outputPath)
 	}
 
 	if err != nil {
-		os.Exit(1)
+		fail(err)
+	}
+
+	// Plain-text output, such as help without --json, is printed as is.
+	if !json.Valid(output) {
+		fmt.Println(string(output))
+		return
+	}
+	if err := format.Write(os.Stdout, *outputFormat, metricPrefix, output); err != nil {
+		fail(err)
+	}
+}
+
+// newGlobalFlagSet returns the flags accepted before the command name.
+func newGlobalFlagSet() (*flag.FlagSet, *int, *string) {
+	fs := flag.NewFlagSet("inspectd", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	pid := fs.Int("pid", 0, "inspect the process with this PID through its embedded agent")
+	outputFormat := fs.String("format", format.JSON, "output format: "+strings.Join(format.Names(), ", ")+"; may also follow the command")
+	return fs, pid, outputFormat
+}
+
+// extractFlag removes every --name <value> and --name=<value> from args and
+// returns the last value, for flags the CLI handles before running a command.
+// A flag without a value, or with an empty one, is a usage error.
+func extractFlag(args []string, name string) (string, []string, error) {
+	var value string
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--"+name || arg == "-"+name:
+			if i+1 >= len(args) {
+				return "", nil, usageError(fmt.Errorf("flag needs an argument: --%s", name))
+			}
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--"+name+"=") || strings.HasPrefix(arg, "-"+name+"="):
+			value = arg[strings.Index(arg, "=")+1:]
+		default:
+			rest = append(rest, arg)
+			continue
+		}
+		if value == "" {
+			return "", nil, usageError(fmt.Errorf("empty value for flag: --%s", name))
+		}
 	}
+	return value, rest, nil
+}
+
+// localCommands are run by the CLI itself rather than passed to command.Run or
+// an agent. Those that inspect a process use pid, or the CLI's own process if 0.
+func localCommands(pid int) []*command.Command {
+	return []*command.Command{
+		{
+			Name:    "trace",
+			Summary: "Execution trace saved to a file and summarized: per-goroutine state times, GC phases and stop-the-world pauses",
+			Output:  exectrace.Summary{},
+			Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+				duration := fs.Duration("duration", exectrace.DefaultDuration, "how long to record the execution trace")
+				output := fs.String("output", "trace.out", "file to write the raw trace to")
+				top := fs.Int("top", exectrace.DefaultTop, "number of goroutines and stop-the-world events to include in the summary")
+				return func(ctx context.Context) ([]byte, error) {
+					return captureTrace(ctx, pid, *duration, *output, *top)
+				}
+			},
+		},
+		{
+			Name:    "ps",
+			Summary: "Local processes with a live inspectd agent",
+			Output:  []process.ProcessInfo{},
+			Setup:   command.NoFlags(listProcesses),
+		},
+		{
+			Name:    "schema",
+			Summary: "JSON Schema document for the output of a command or for the SDK snapshot",
+			Args:    "<type>",
+			Output:  schema.Schema{},
+			Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+				return func(context.Context) ([]byte, error) {
+					return schemaJSON(fs.Args())
+				}
+			},
+		},
+		{
+			Name:    "version",
+			Summary: "Version and build information of the inspectd binary",
+			Output:  versionInfo{},
+			Setup:   command.NoFlags(versionJSON),
+		},
+		{
+			Name:    "help",
+			Summary: "Usage, or with --json a catalog of commands, flags, output schemas and exit codes",
+			Output:  catalog{},
+			Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+				asJSON := fs.Bool("json", false, "print the machine-readable catalog")
+				return func(context.Context) ([]byte, error) {
+					if *asJSON {
+						return catalogJSON()
+					}
+					return usage(), nil
+				}
+			},
+		},
+	}
+}
 
-	fmt.Println(string(output))
+// localOnly reports whether a local command ignores --pid and always describes the CLI itself.
+func localOnly(name string) bool {
+	return name == "ps" || name == "schema" || name == "version" || name == "help"
+}
+
+func findLocal(commands []*command.Command, name string) *command.Command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
 }
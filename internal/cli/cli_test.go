@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Aldiwildan77/inspectd/internal/command"
+)
+
+func TestExtractFlag(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantValue string
+		wantRest  []string
+	}{
+		{[]string{"--top", "5"}, "", []string{"--top", "5"}},
+		{[]string{"--format", "table", "--top", "5"}, "table", []string{"--top", "5"}},
+		{[]string{"--top", "5", "--format=ndjson"}, "ndjson", []string{"--top", "5"}},
+		{[]string{"-format", "logfmt"}, "logfmt", []string{}},
+		{[]string{"-format=logfmt"}, "logfmt", []string{}},
+		{[]string{"--format", "json", "--format", "table"}, "table", []string{}},
+		{[]string{"--formats", "x"}, "", []string{"--formats", "x"}},
+	}
+	for _, tt := range tests {
+		value, rest, err := extractFlag(tt.args, "format")
+		if err != nil {
+			t.Errorf("extractFlag(%q) error = %v", tt.args, err)
+			continue
+		}
+		if value != tt.wantValue || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("extractFlag(%q) = %q, %q, want %q, %q", tt.args, value, rest, tt.wantValue, tt.wantRest)
+		}
+	}
+}
+
+func TestExtractFlagUsageErrors(t *testing.T) {
+	tests := [][]string{
+		{"--format="},
+		{"-format="},
+		{"--format", ""},
+		{"--top", "5", "--format"},
+		{"--format", "table", "--format="},
+	}
+	for _, args := range tests {
+		_, _, err := extractFlag(args, "format")
+		var cmdErr *command.Error
+		if !errors.As(err, &cmdErr) || cmdErr.Code != command.CodeUsage {
+			t.Errorf("extractFlag(%q) error = %v, want a usage error", args, err)
+		}
+	}
+}
+
+func TestExtractOutputFlag(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantPath string
+		wantRest []string
+		wantErr  bool
+	}{
+		{[]string{"cpu", "--top", "5"}, "", []string{"cpu", "--top", "5"}, false},
+		{[]string{"cpu", "--output", "cpu.pprof"}, "cpu.pprof", []string{"cpu", "--raw"}, false},
+		{[]string{"heap", "--output=heap.pprof", "--fields", "top_sites"}, "heap.pprof", []string{"heap", "--raw", "--fields", "top_sites,pprof"}, false},
+		{[]string{"cpu", "--output="}, "", nil, true},
+		{[]string{"cpu", "--output", "cpu.pprof", "--fields="}, "", nil, true},
+	}
+	for _, tt := range tests {
+		path, rest, err := extractOutputFlag(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("extractOutputFlag(%q) error = %v, want error %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if path != tt.wantPath || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("extractOutputFlag(%q) = %q, %q, want %q, %q", tt.args, path, rest, tt.wantPath, tt.wantRest)
+		}
+	}
+}
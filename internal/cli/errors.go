@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Aldiwildan77/inspectd/internal/attach"
+	"github.com/Aldiwildan77/inspectd/internal/command"
+)
+
+// Exit codes. Each failure also prints a JSON error object to stderr.
+const (
+	exitCollectionFailed = 1
+	exitUsage            = 2
+	exitUnknownCommand   = 3
+	exitAttachFailed     = 4
+)
+
+// Error codes for failures that do not come from the command package.
+const (
+	codeCollectionFailed = "collection_failed"
+	codeAttachFailed     = "attach_failed"
+)
+
+// exitCodes documents the exit codes in the `help --json` catalog.
+var exitCodes = []exitCodeInfo{
+	{Code: 0, Meaning: "success"},
+	{Code: exitCollectionFailed, ErrorCode: codeCollectionFailed, Meaning: "collecting or capturing the data failed"},
+	{Code: exitUsage, ErrorCode: command.CodeUsage, Meaning: "missing command, invalid flag or flag value"},
+	{Code: exitUnknownCommand, ErrorCode: command.CodeUnknownCommand, Meaning: "the command does not exist"},
+	{Code: exitAttachFailed, ErrorCode: codeAttachFailed, Meaning: "no agent reachable in the --pid process"},
+}
+
+type errorOutput struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// attachError is a failure to reach or talk to the agent in the target process.
+type attachError struct {
+	pid int
+	err error
+}
+
+func (e *attachError) Error() string { return e.err.Error() }
+
+func (e *attachError) Unwrap() error { return e.err }
+
+// callAgent runs a command through the agent in pid, marking failures to reach
+// the agent as attach errors so they are reported separately from collection failures.
+func callAgent(ctx context.Context, pid int, name string, args []string) ([]byte, error) {
+	data, err := attach.Call(ctx, pid, name, args)
+	if err != nil {
+		var remote *attach.RemoteError
+		if errors.As(err, &remote) {
+			return nil, err
+		}
+		return nil, &attachError{pid: pid, err: err}
+	}
+	return data, nil
+}
+
+func usageError(err error) error {
+	return &command.Error{Code: command.CodeUsage, Err: err}
+}
+
+// fail prints err to stderr as a JSON error object and exits with the matching exit code.
+func fail(err error) {
+	body, exitCode := classify(err)
+	data, marshalErr := json.Marshal(errorOutput{Error: body})
+	if marshalErr != nil {
+		data = []byte(fmt.Sprintf(`{"error":{"code":%q,"message":%q}}`, body.Code, body.Message))
+	}
+	fmt.Fprintln(os.Stderr, string(data))
+	os.Exit(exitCode)
+}
+
+func classify(err error) (errorBody, int) {
+	body := errorBody{Code: codeCollectionFailed, Message: err.Error()}
+
+	code := ""
+	var cmdErr *command.Error
+	var remote *attach.RemoteError
+	var attachErr *attachError
+	switch {
+	case errors.As(err, &cmdErr):
+		code = cmdErr.Code
+	case errors.As(err, &remote):
+		code = remote.Code
+	case errors.As(err, &attachErr):
+		body.Code = codeAttachFailed
+		body.Hint = fmt.Sprintf("start the agent in process %d with agent.Start() from sdk/agent; sockets are looked up in %s (INSPECTD_SOCKET_DIR)", attachErr.pid, attach.SocketDir())
+		return body, exitAttachFailed
+	}
+
+	switch code {
+	case command.CodeUsage:
+		body.Code = code
+		body.Hint = "usage: " + usageLine + "; run `inspectd help --json` for commands and flags"
+		return body, exitUsage
+	case command.CodeUnknownCommand:
+		body.Code = code
+		body.Hint = "available commands: " + strings.Join(commandNames(), ", ")
+		return body, exitUnknownCommand
+	default:
+		return body, exitCollectionFailed
+	}
+}
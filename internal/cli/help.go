@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Aldiwildan77/inspectd/internal/command"
+	"github.com/Aldiwildan77/inspectd/internal/schema"
+)
+
+const usageLine = "inspectd [--pid <pid>] [--format <format>] <command> [flags]"
+
+// catalog is the machine-readable description of the CLI printed by `help --json`.
+type catalog struct {
+	Name        string         `json:"name"`
+	Version     string         `json:"version"`
+	Usage       string         `json:"usage"`
+	GlobalFlags []command.Flag `json:"global_flags"`
+	Commands    []commandInfo  `json:"commands"`
+	ExitCodes   []exitCodeInfo `json:"exit_codes"`
+}
+
+type commandInfo struct {
+	Name        string         `json:"name"`
+	Summary     string         `json:"summary"`
+	Args        string         `json:"args,omitempty"`
+	SupportsPID bool           `json:"supports_pid"`
+	Flags       []command.Flag `json:"flags"`
+	Subcommands []commandInfo  `json:"subcommands,omitempty"`
+	Output      *schema.Schema `json:"output,omitempty"`
+}
+
+type exitCodeInfo struct {
+	Code      int    `json:"code"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Meaning   string `json:"meaning"`
+}
+
+// profileOutputFlag is handled by the CLI for every profile type, see extractOutputFlag.
+var profileOutputFlag = command.Flag{
+	Name:  "output",
+	Type:  "string",
+	Usage: "also write the raw pprof profile to this file; written by the CLI, also with --pid",
+}
+
+// cliCommands returns every command the CLI accepts in catalog order: the
+// inspection commands, with the CLI's own versions replacing them where it
+// has one, followed by the local-only commands.
+func cliCommands() []*command.Command {
+	local := localCommands(0)
+	commands := command.Commands()
+	for i, cmd := range commands {
+		if override := findLocal(local, cmd.Name); override != nil {
+			commands[i] = override
+		}
+	}
+	for _, cmd := range local {
+		if localOnly(cmd.Name) {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+func collectCatalog() (*catalog, error) {
+	info, err := collectVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	fs, _, _ := newGlobalFlagSet()
+	c := &catalog{
+		Name:        "inspectd",
+		Version:     info.Version,
+		Usage:       usageLine,
+		GlobalFlags: command.FlagsOf(fs),
+		ExitCodes:   exitCodes,
+	}
+	for _, cmd := range cliCommands() {
+		c.Commands = append(c.Commands, describe(cmd, cmd.Name, !localOnly(cmd.Name)))
+	}
+	return c, nil
+}
+
+func describe(cmd *command.Command, path string, supportsPID bool) commandInfo {
+	info := commandInfo{
+		Name:        cmd.Name,
+		Summary:     cmd.Summary,
+		Args:        cmd.Args,
+		SupportsPID: supportsPID,
+		Flags:       cmd.Flags(),
+	}
+	if strings.HasPrefix(path, "profile ") {
+		info.Flags = append(info.Flags, profileOutputFlag)
+		slices.SortFunc(info.Flags, func(a, b command.Flag) int { return strings.Compare(a.Name, b.Name) })
+	}
+	for _, sub := range cmd.Subcommands {
+		info.Subcommands = append(info.Subcommands, describe(sub, path+" "+sub.Name, supportsPID))
+	}
+	if cmd.Output != nil {
+		info.Output = schema.Of(cmd.Output)
+	}
+	return info
+}
+
+func catalogJSON() ([]byte, error) {
+	c, err := collectCatalog()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+// usage returns the plain-text command list printed by `help` without --json.
+func usage() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "usage: %s\n\ncommands:\n", usageLine)
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	for _, cmd := range cliCommands() {
+		if len(cmd.Subcommands) == 0 {
+			fmt.Fprintf(w, "  %s\t%s\n", cmd.Name, cmd.Summary)
+		}
+		for _, sub := range cmd.Subcommands {
+			fmt.Fprintf(w, "  %s %s\t%s\n", cmd.Name, sub.Name, sub.Summary)
+		}
+	}
+	w.Flush()
+	buf.WriteString("\nrun `inspectd help --json` for flags, output schemas and exit codes")
+	return buf.Bytes()
+}
+
+// commandNames lists the top-level commands for error hints.
+func commandNames() []string {
+	var names []string
+	for _, cmd := range cliCommands() {
+		names = append(names, cmd.Name)
+	}
+	return names
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// extractOutputFlag removes --output <file> from profile arguments and asks the
+// command for the raw profile instead, so the file is written by the CLI even
+// when the profile is captured in an attached process. A --fields selection is
+// extended to keep the raw profile in the output.
+func extractOutputFlag(args []string) (string, []string, error) {
+	path, rest, err := extractFlag(args, "output")
+	if err != nil || path == "" {
+		return "", rest, err
+	}
+
+	rest = append(rest, "--raw")
+	list, withoutFields, err := extractFlag(rest, "fields")
+	if err != nil {
+		return "", nil, err
+	}
+	if list != "" {
+		rest = append(withoutFields, "--fields", list+",pprof")
+	}
+	return path, rest, nil
+}
+
+// writeRawProfile writes the pprof data from a profile result to path and
+// replaces it in the output with the path it was written to.
+func writeRawProfile(output []byte, path string) ([]byte, error) {
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode profile output: %w", err)
+	}
+
+	var data []byte
+	if err := json.Unmarshal(result["pprof"], &data); err != nil {
+		return nil, fmt.Errorf("failed to decode pprof data: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write profile: %w", err)
+	}
+
+	delete(result, "pprof")
+	encodedPath, err := json.Marshal(path)
+	if err != nil {
+		return nil, err
+	}
+	result["output"] = encodedPath
+	return json.Marshal(result)
+}
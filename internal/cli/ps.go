@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/attach"
+	"github.com/Aldiwildan77/inspectd/internal/process"
+)
+
+// psTimeout bounds how long ps waits for each agent, so one hung process cannot stall the listing.
+const psTimeout = 2 * time.Second
+
+// listProcesses asks every agent socket for its process info and returns the live ones as a JSON array.
+func listProcesses() ([]byte, error) {
+	pids, err := attach.ListPIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	processes := make([]*process.ProcessInfo, 0, len(pids))
+	for _, pid := range pids {
+		ctx, cancel := context.WithTimeout(context.Background(), psTimeout)
+		data, err := attach.Call(ctx, pid, "process", nil)
+		cancel()
+		if err != nil {
+			continue // Stale socket or unresponsive agent
+		}
+
+		var info process.ProcessInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			continue
+		}
+		processes = append(processes, &info)
+	}
+
+	return json.Marshal(processes)
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Aldiwildan77/inspectd/internal/schema"
+	"github.com/Aldiwildan77/inspectd/sdk/types"
+)
+
+// sdkSnapshotType names the schema of types.Snapshot, the snapshot stored by the SDK.
+const sdkSnapshotType = "sdk-snapshot"
+
+// schemaTypes returns the outputs `schema` can describe by name: every
+// command and subcommand (as "profile cpu") plus the SDK snapshot.
+func schemaTypes() ([]string, map[string]any) {
+	var names []string
+	outputs := make(map[string]any)
+	add := func(name string, output any) {
+		if output != nil {
+			names = append(names, name)
+			outputs[name] = output
+		}
+	}
+	for _, cmd := range cliCommands() {
+		add(cmd.Name, cmd.Output)
+		for _, sub := range cmd.Subcommands {
+			add(cmd.Name+" "+sub.Name, sub.Output)
+		}
+	}
+	add(sdkSnapshotType, types.Snapshot{})
+	return names, outputs
+}
+
+func schemaJSON(args []string) ([]byte, error) {
+	names, outputs := schemaTypes()
+	if len(args) == 0 {
+		return nil, usageError(fmt.Errorf("missing schema type (expected one of: %s)", strings.Join(names, ", ")))
+	}
+
+	name := strings.Join(args, " ")
+	output, ok := outputs[name]
+	if !ok {
+		return nil, usageError(fmt.Errorf("unknown schema type: %s (expected one of: %s)", name, strings.Join(names, ", ")))
+	}
+	return json.Marshal(schema.Document("inspectd "+name, output))
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/command"
+	"github.com/Aldiwildan77/inspectd/internal/exectrace"
+)
+
+// traceSummaryTimeout bounds how long `go tool trace` may take to parse a capture.
+const traceSummaryTimeout = 5 * time.Minute
+
+// captureTrace records a trace in the inspected process, writes it to a local
+// file and returns the JSON summary. Only the capture runs in the target; the
+// summary needs the go toolchain, which is only required where the CLI runs.
+func captureTrace(ctx context.Context, pid int, duration time.Duration, output string, top int) ([]byte, error) {
+	traceArgs := []string{"--duration", duration.String()}
+	var data []byte
+	var err error
+	if pid != 0 {
+		data, err = callAgent(ctx, pid, "trace", traceArgs)
+	} else {
+		data, err = command.Run(ctx, "trace", traceArgs)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var capture exectrace.Capture
+	if err := json.Unmarshal(data, &capture); err != nil {
+		return nil, fmt.Errorf("failed to decode trace: %w", err)
+	}
+	if err := os.WriteFile(output, capture.Trace, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write trace: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, traceSummaryTimeout)
+	defer cancel()
+	summary, err := exectrace.Summarize(ctx, output, top)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(summary)
+}
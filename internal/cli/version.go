@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"encoding/json"
+	"runtime"
+
+	"github.com/Aldiwildan77/inspectd/internal/buildinfo"
+)
+
+// version can be set at build time with
+// -ldflags "-X github.com/Aldiwildan77/inspectd/internal/cli.version=v1.2.3".
+// Otherwise the module version recorded by `go install ...@version` is reported.
+var version string
+
+type versionInfo struct {
+	Version   string               `json:"version"`
+	GoVersion string               `json:"go_version"`
+	Build     *buildinfo.BuildInfo `json:"build,omitempty"`
+}
+
+func collectVersion() (*versionInfo, error) {
+	build, err := buildinfo.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &versionInfo{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		Build:     build,
+	}
+	if info.Version == "" && build != nil {
+		info.Version = build.Main.Version
+	}
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+	return info, nil
+}
+
+func versionJSON() ([]byte, error) {
+	info, err := collectVersion()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
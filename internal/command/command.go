@@ -0,0 +1,367 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/buildinfo"
+	"github.com/Aldiwildan77/inspectd/internal/contention"
+	"github.com/Aldiwildan77/inspectd/internal/exectrace"
+	"github.com/Aldiwildan77/inspectd/internal/fields"
+	"github.com/Aldiwildan77/inspectd/internal/goroutines"
+	"github.com/Aldiwildan77/inspectd/internal/memory"
+	"github.com/Aldiwildan77/inspectd/internal/osinfo"
+	"github.com/Aldiwildan77/inspectd/internal/process"
+	"github.com/Aldiwildan77/inspectd/internal/profile"
+	"github.com/Aldiwildan77/inspectd/internal/rtmetrics"
+	"github.com/Aldiwildan77/inspectd/internal/runtimeinfo"
+	"github.com/Aldiwildan77/inspectd/internal/scheduler"
+	"github.com/Aldiwildan77/inspectd/internal/schema"
+	"github.com/Aldiwildan77/inspectd/internal/snapshot"
+)
+
+// Command is an inspection command. Commands run in the current process, either
+// from the CLI directly or from an attached agent on behalf of a remote CLI.
+type Command struct {
+	Name    string
+	Summary string
+	// Args describes the positional arguments the command accepts after its flags, if any.
+	Args string
+	// Output is a value of the type the command encodes as JSON, used to describe its output.
+	Output any
+	// Subcommands are selected by the first argument, as in `profile cpu`.
+	Subcommands []*Command
+	// Setup registers the command's flags and returns the function that runs it once they are parsed.
+	// Commands that collect over a duration stop early when the context is cancelled.
+	Setup func(fs *flag.FlagSet) func(ctx context.Context) ([]byte, error)
+}
+
+// fieldsFlag selects the output fields of every command; see Run.
+const (
+	fieldsFlag  = "fields"
+	fieldsUsage = "comma-separated dotted paths of the output fields to include, e.g. memory.heap_in_use_bytes"
+)
+
+// Flag describes a command-line flag.
+type Flag struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+}
+
+var commands = []*Command{
+	{
+		Name:    "runtime",
+		Summary: "Go version, goroutine count, GOMAXPROCS, CPU count, uptime, cgroup limits and runtime settings",
+		Output:  runtimeinfo.RuntimeInfo{},
+		Setup:   NoFlags(runtimeinfo.CollectJSON),
+	},
+	{
+		Name:    "memory",
+		Summary: "Heap usage, allocations, GC statistics and pause percentiles, and a breakdown of runtime-mapped memory",
+		Output:  memory.MemoryInfo{},
+		Setup:   NoFlags(memory.CollectJSON),
+	},
+	{
+		Name:    "goroutines",
+		Summary: "Goroutine count, optionally with parsed stacks, state counts and identical-stack groups",
+		Output:  goroutines.GoroutineInfo{},
+		Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+			stacks := fs.Bool("stacks", false, "include a parsed stack dump of every goroutine")
+			groups := fs.Bool("groups", false, "include state counts and identical-stack groups without per-goroutine records")
+			return func(context.Context) ([]byte, error) {
+				if *stacks {
+					return goroutines.CollectStacksJSON()
+				}
+				if *groups {
+					return goroutines.CollectGroupsJSON()
+				}
+				return goroutines.CollectJSON()
+			}
+		},
+	},
+	{
+		Name:    "os",
+		Summary: "Process RSS, CPU time, file descriptors, threads and context switches from /proc (Linux only)",
+		Output:  osinfo.OSInfo{},
+		Setup:   NoFlags(osinfo.CollectJSON),
+	},
+	{
+		Name:    "scheduler",
+		Summary: "Scheduling latency, goroutine states, thread count and GOMAXPROCS history",
+		Output:  scheduler.SchedulerInfo{},
+		Setup:   NoFlags(scheduler.CollectJSON),
+	},
+	{
+		Name:    "snapshot",
+		Summary: "Runtime, memory, goroutine, scheduler, OS and build information with a timestamp",
+		Output:  snapshot.Snapshot{},
+		Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+			// Registered here rather than by Run so only the requested sections are collected.
+			list := fs.String(fieldsFlag, "", fieldsUsage)
+			return func(context.Context) ([]byte, error) {
+				paths, err := fields.Parse(*list)
+				if err != nil {
+					return nil, err
+				}
+				return snapshot.CollectSectionsJSON(fields.Sections(paths)...)
+			}
+		},
+	},
+	{
+		Name:    "contention",
+		Summary: "Call sites with the most mutex and block wait time over a bounded profiling window",
+		Output:  contention.ContentionInfo{},
+		Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+			duration := fs.Duration("duration", contention.DefaultDuration, "how long to record mutex events, then block events")
+			top := fs.Int("top", contention.DefaultTop, "number of call sites to report per profile")
+			return func(ctx context.Context) ([]byte, error) {
+				if err := checkDuration(*duration, contention.MaxDuration); err != nil {
+					return nil, err
+				}
+				return contention.CollectJSON(ctx, contention.Options{Duration: *duration, Top: *top})
+			}
+		},
+	},
+	{
+		Name:    "profile",
+		Summary: "CPU or heap profile summarized as the top functions",
+		Subcommands: []*Command{
+			{
+				Name:    "cpu",
+				Summary: "Top functions by flat and cumulative CPU time over a bounded duration",
+				Output:  profile.CPUProfile{},
+				Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+					duration := fs.Duration("duration", profile.DefaultCPUDuration, "how long to run the CPU profiler")
+					top := fs.Int("top", profile.DefaultTop, "number of functions to report")
+					raw := fs.Bool("raw", false, "include the gzipped pprof profile in the output")
+					return func(ctx context.Context) ([]byte, error) {
+						if err := checkDuration(*duration, profile.MaxCPUDuration); err != nil {
+							return nil, err
+						}
+						return profile.CollectCPUJSON(ctx, profile.CPUOptions{Duration: *duration, Top: *top, Raw: *raw})
+					}
+				},
+			},
+			{
+				Name:    "heap",
+				Summary: "In-use and allocated memory by allocating function and allocation site",
+				Output:  profile.HeapProfile{},
+				Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+					top := fs.Int("top", profile.DefaultTop, "number of functions and allocation sites to report")
+					sortBy := fs.String("sort", profile.SortInUse, "rank by in-use (inuse) or total allocated (alloc) bytes")
+					gc := fs.Bool("gc", false, "run a garbage collection first so in-use values are current")
+					raw := fs.Bool("raw", false, "include the gzipped pprof profile in the output")
+					return func(context.Context) ([]byte, error) {
+						if *sortBy != profile.SortInUse && *sortBy != profile.SortAlloc {
+							return nil, usageError(fmt.Errorf("unknown sort order: %s (expected %s or %s)", *sortBy, profile.SortInUse, profile.SortAlloc))
+						}
+						return profile.CollectHeapJSON(profile.HeapOptions{Top: *top, SortBy: *sortBy, GC: *gc, Raw: *raw})
+					}
+				},
+			},
+		},
+	},
+	{
+		// Returns the raw trace; the CLI saves and summarizes it where the go toolchain is available.
+		Name:    "trace",
+		Summary: "Raw runtime/trace execution trace recorded for a bounded duration",
+		Output:  exectrace.Capture{},
+		Setup: func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+			duration := fs.Duration("duration", exectrace.DefaultDuration, "how long to record the execution trace")
+			return func(ctx context.Context) ([]byte, error) {
+				if err := checkDuration(*duration, exectrace.MaxDuration); err != nil {
+					return nil, err
+				}
+				return exectrace.CollectJSON(ctx, *duration)
+			}
+		},
+	},
+	{
+		Name:    "build",
+		Summary: "Go version, main module, VCS revision, build settings and dependencies of the binary",
+		Output:  buildinfo.BuildInfo{},
+		Setup:   NoFlags(buildinfo.CollectJSON),
+	},
+	{
+		Name:    "metrics",
+		Summary: "Every sample from runtime/metrics",
+		Output:  []rtmetrics.Metric{},
+		Setup:   NoFlags(rtmetrics.CollectJSON),
+	},
+	{
+		Name:    "process",
+		Summary: "PID, executable path, Go version, uptime and main module",
+		Output:  process.ProcessInfo{},
+		Setup:   NoFlags(process.CollectJSON),
+	},
+}
+
+// Commands returns every inspection command in catalog order.
+func Commands() []*Command {
+	return append([]*Command(nil), commands...)
+}
+
+// Lookup returns the named command, or nil if there is none.
+func Lookup(name string) *Command {
+	return find(commands, name)
+}
+
+// Run executes an inspection command in the current process and returns its JSON output.
+// The CLI calls it directly; an attached agent calls it on behalf of a remote CLI.
+func Run(ctx context.Context, name string, args []string) ([]byte, error) {
+	cmd := Lookup(name)
+	if cmd == nil {
+		return nil, unknownCommand(name)
+	}
+	return cmd.Run(ctx, args)
+}
+
+// Run parses args, selecting a subcommand first if the command has them, and runs the command.
+func (c *Command) Run(ctx context.Context, args []string) ([]byte, error) {
+	if len(c.Subcommands) > 0 {
+		if len(args) < 1 {
+			return nil, usageError(fmt.Errorf("missing %s type (expected %s)", c.Name, c.subcommandNames()))
+		}
+		sub := find(c.Subcommands, args[0])
+		if sub == nil {
+			return nil, usageError(fmt.Errorf("unknown %s type: %s (expected %s)", c.Name, args[0], c.subcommandNames()))
+		}
+		return sub.Run(ctx, args[1:])
+	}
+
+	fs, run := c.flagSet()
+	if err := fs.Parse(args); err != nil {
+		return nil, usageError(err)
+	}
+	if fs.NArg() > 0 && c.Args == "" {
+		return nil, usageError(fmt.Errorf("unexpected argument: %s", fs.Arg(0)))
+	}
+
+	// Check --fields against the output schema before collecting anything.
+	paths, err := fields.Parse(fs.Lookup(fieldsFlag).Value.String())
+	if err != nil {
+		return nil, usageError(err)
+	}
+	if len(paths) == 0 && isSet(fs, fieldsFlag) {
+		return nil, usageError(fmt.Errorf("empty value for flag: --%s", fieldsFlag))
+	}
+	if len(paths) > 0 && c.Output != nil {
+		s := schema.Of(c.Output)
+		for _, path := range paths {
+			if s.Field(path) == nil {
+				return nil, usageError(fmt.Errorf("unknown field: %s", strings.Join(path, ".")))
+			}
+		}
+	}
+
+	output, err := run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fields.Project(output, paths)
+}
+
+// flagSet registers the command's flags, plus --fields unless the command
+// handles it itself, and returns the function that runs the command.
+func (c *Command) flagSet() (*flag.FlagSet, func(context.Context) ([]byte, error)) {
+	fs := newFlagSet(c.Name)
+	run := c.Setup(fs)
+	if fs.Lookup(fieldsFlag) == nil {
+		fs.String(fieldsFlag, "", fieldsUsage)
+	}
+	return fs, run
+}
+
+// Flags describes the command's own flags.
+func (c *Command) Flags() []Flag {
+	if c.Setup == nil {
+		return []Flag{}
+	}
+	fs, _ := c.flagSet()
+	return FlagsOf(fs)
+}
+
+// FlagsOf describes the flags registered on fs in lexical order.
+func FlagsOf(fs *flag.FlagSet) []Flag {
+	flags := []Flag{}
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, Flag{Name: f.Name, Type: flagType(f.Value), Default: f.DefValue, Usage: f.Usage})
+	})
+	return flags
+}
+
+// NoFlags is the Setup of a command without flags.
+func NoFlags(run func() ([]byte, error)) func(fs *flag.FlagSet) func(context.Context) ([]byte, error) {
+	return func(*flag.FlagSet) func(context.Context) ([]byte, error) {
+		return func(context.Context) ([]byte, error) { return run() }
+	}
+}
+
+// checkDuration rejects a --duration over the collector's limit as a usage
+// error before anything is collected; the collectors check it again for SDK callers.
+func checkDuration(d, max time.Duration) error {
+	if d > max {
+		return usageError(fmt.Errorf("duration %s exceeds the maximum of %s", d, max))
+	}
+	return nil
+}
+
+// isSet reports whether the flag was given on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func (c *Command) subcommandNames() string {
+	names := make([]string, len(c.Subcommands))
+	for i, sub := range c.Subcommands {
+		names[i] = sub.Name
+	}
+	return strings.Join(names, " or ")
+}
+
+func find(commands []*Command, name string) *Command {
+	for _, cmd := range commands {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func flagType(value flag.Value) string {
+	getter, ok := value.(flag.Getter)
+	if !ok {
+		return "string"
+	}
+	switch getter.Get().(type) {
+	case bool:
+		return "bool"
+	case time.Duration:
+		return "duration"
+	case int, int64, uint, uint64:
+		return "int"
+	case float64:
+		return "float"
+	default:
+		return "string"
+	}
+}
+
+// newFlagSet returns a flag set for a command's own flags that reports errors instead of printing them.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
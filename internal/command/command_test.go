@@ -0,0 +1,41 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRunErrorCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		code string
+	}{
+		{"bogus", nil, CodeUnknownCommand},
+		{"goroutines", []string{"--bogus"}, CodeUsage},
+		{"runtime", []string{"extra"}, CodeUsage},
+		{"profile", nil, CodeUsage},
+		{"profile", []string{"gpu"}, CodeUsage},
+		{"profile", []string{"heap", "--sort", "bogus"}, CodeUsage},
+		{"profile", []string{"cpu", "--duration", "6m"}, CodeUsage},
+		{"contention", []string{"--duration", "6m"}, CodeUsage},
+		{"trace", []string{"--duration", "2m"}, CodeUsage},
+		{"snapshot", []string{"--fields", "memory.bogus"}, CodeUsage},
+		{"snapshot", []string{"--fields", "memory..heap_in_use_bytes"}, CodeUsage},
+		{"snapshot", []string{"--fields="}, CodeUsage},
+		{"memory", []string{"--fields", " , "}, CodeUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Run(context.Background(), tt.name, tt.args)
+			var cmdErr *Error
+			if !errors.As(err, &cmdErr) {
+				t.Fatalf("Run(%q, %q) error = %v, want a command error", tt.name, tt.args, err)
+			}
+			if cmdErr.Code != tt.code {
+				t.Errorf("Run(%q, %q) code = %s, want %s", tt.name, tt.args, cmdErr.Code, tt.code)
+			}
+		})
+	}
+}
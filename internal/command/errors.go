@@ -0,0 +1,31 @@
+package command
+
+import "fmt"
+
+// Error codes that tell callers why a command could not run, as opposed to failing while collecting.
+const (
+	CodeUsage          = "usage"
+	CodeUnknownCommand = "unknown_command"
+)
+
+// Error is a failure to run a command because of how it was invoked.
+// The code survives the trip from an attached agent back to the CLI.
+type Error struct {
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string { return e.Err.Error() }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// ErrorCode reports the error code; attach.ServeConn forwards it to the CLI.
+func (e *Error) ErrorCode() string { return e.Code }
+
+func usageError(err error) error {
+	return &Error{Code: CodeUsage, Err: err}
+}
+
+func unknownCommand(name string) error {
+	return &Error{Code: CodeUnknownCommand, Err: fmt.Errorf("unknown command: %s", name)}
+}
@@ -0,0 +1,274 @@
+package contention
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/profile"
+)
+
+const (
+	DefaultDuration = 5 * time.Second
+	DefaultTop      = 10
+
+	// MaxDuration bounds how long profiling stays enabled in the target process.
+	MaxDuration = 5 * time.Minute
+)
+
+type Options struct {
+	Duration time.Duration
+	Top      int
+}
+
+type ContentionInfo struct {
+	Duration float64    `json:"duration_seconds"`
+	Mutex    []CallSite `json:"mutex"`
+	Block    []CallSite `json:"block"`
+}
+
+// CallSite aggregates contention by the first frame outside the runtime and
+// sync packages. Frames is the stack that contributed the most wait time.
+type CallSite struct {
+	Function string          `json:"function"`
+	File     string          `json:"file"`
+	Line     int             `json:"line"`
+	Count    int64           `json:"count"`
+	Wait     float64         `json:"wait_seconds"`
+	Frames   []profile.Frame `json:"frames"`
+}
+
+// capturing prevents overlapping captures from clobbering each other's profile rates.
+var capturing sync.Mutex
+
+// Collect records a mutex profiling window and then a block profiling window,
+// each lasting the duration, and returns the contention recorded in them.
+// Cancelling ctx ends the capture early. The windows do not overlap because
+// the runtime misreports mutex delays while block profiling is also enabled.
+// Profiling is switched back off afterwards; the mutex fraction is restored to
+// its previous value, but the runtime has no getter for the block rate, so it is reset to 0.
+func Collect(ctx context.Context, opts Options) (*ContentionInfo, error) {
+	if opts.Duration <= 0 {
+		opts.Duration = DefaultDuration
+	}
+	if opts.Duration > MaxDuration {
+		return nil, fmt.Errorf("duration %s exceeds the maximum of %s", opts.Duration, MaxDuration)
+	}
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+
+	if !capturing.TryLock() {
+		return nil, errors.New("a contention capture is already running")
+	}
+	defer capturing.Unlock()
+
+	var previousFraction int
+	mutex, mutexElapsed, err := record(ctx, "mutex", opts,
+		func() { previousFraction = runtime.SetMutexProfileFraction(1) },
+		func() { runtime.SetMutexProfileFraction(previousFraction) })
+	if err != nil {
+		return nil, err
+	}
+	block, blockElapsed, err := record(ctx, "block", opts,
+		func() { runtime.SetBlockProfileRate(1) },
+		func() { runtime.SetBlockProfileRate(0) })
+	if err != nil {
+		return nil, err
+	}
+
+	return &ContentionInfo{
+		Duration: max(mutexElapsed, blockElapsed).Seconds(),
+		Mutex:    mutex,
+		Block:    block,
+	}, nil
+}
+
+// record runs one profiling window between enable and disable and returns the
+// top call sites recorded in it. Profiles are cumulative, so the window is the
+// difference between a read before and after it.
+func record(ctx context.Context, name string, opts Options, enable, disable func()) ([]CallSite, time.Duration, error) {
+	before, err := readProfile(name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	enable()
+	timer := time.NewTimer(opts.Duration)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	disable()
+	elapsed := time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	after, err := readProfile(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	sites, err := topCallSites(before, after, elapsed, opts.Top)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s profile: %w", name, err)
+	}
+	return sites, elapsed, nil
+}
+
+func CollectJSON(ctx context.Context, opts Options) ([]byte, error) {
+	info, err := Collect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
+
+func readProfile(name string) (*profile.Profile, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+		return nil, fmt.Errorf("failed to read %s profile: %w", name, err)
+	}
+	return profile.Parse(buf.Bytes())
+}
+
+type stackTotals struct {
+	count int64
+	delay int64
+	stack []profile.Frame
+}
+
+// totalsByStack indexes the contentions and delay (in nanoseconds) of each stack.
+func totalsByStack(p *profile.Profile) map[string]*stackTotals {
+	countIdx, delayIdx := p.ValueIndex("contentions"), p.ValueIndex("delay")
+	totals := make(map[string]*stackTotals)
+	if countIdx < 0 || delayIdx < 0 {
+		return totals
+	}
+
+	for _, s := range p.Samples {
+		if isCapture(s.Stack) {
+			continue
+		}
+		key := stackKey(s.Stack)
+		t, ok := totals[key]
+		if !ok {
+			t = &stackTotals{stack: s.Stack}
+			totals[key] = t
+		}
+		t.count += s.Values[countIdx]
+		t.delay += s.Values[delayIdx]
+	}
+	return totals
+}
+
+// topCallSites attributes the contention recorded between before and after to
+// call sites. Each contention waited at most the window, so a stack whose delay
+// exceeds its count times the window means the profile is corrupt and is an error.
+func topCallSites(before, after *profile.Profile, window time.Duration, top int) ([]CallSite, error) {
+	baseline := totalsByStack(before)
+
+	type site struct {
+		CallSite
+		delay    int64
+		heaviest int64
+	}
+	sites := make(map[profile.Frame]*site)
+
+	for key, t := range totalsByStack(after) {
+		count, delay := t.count, t.delay
+		if b, ok := baseline[key]; ok {
+			count -= b.count
+			delay -= b.delay
+		}
+		if count <= 0 && delay <= 0 {
+			continue
+		}
+		if delay > count*int64(window) {
+			return nil, fmt.Errorf("implausible delay of %s over %d contentions in a %s window at %s", time.Duration(delay), count, window, callSite(t.stack).Function)
+		}
+
+		caller := callSite(t.stack)
+		s, ok := sites[caller]
+		if !ok {
+			s = &site{CallSite: CallSite{Function: caller.Function, File: caller.File, Line: caller.Line}}
+			sites[caller] = s
+		}
+		s.Count += count
+		s.delay += delay
+		if s.Frames == nil || delay > s.heaviest {
+			s.heaviest = delay
+			s.Frames = t.stack
+		}
+	}
+
+	out := make([]CallSite, 0, len(sites))
+	for _, s := range sites {
+		s.Wait = time.Duration(s.delay).Seconds()
+		out = append(out, s.CallSite)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Wait != out[j].Wait {
+			return out[i].Wait > out[j].Wait
+		}
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Function < out[j].Function
+	})
+	if len(out) > top {
+		out = out[:top]
+	}
+	return out, nil
+}
+
+// callSite returns the first frame outside the runtime and sync packages,
+// or the leaf frame when the whole stack is internal.
+func callSite(stack []profile.Frame) profile.Frame {
+	for _, f := range stack {
+		if !isInternal(f.Function) {
+			return f
+		}
+	}
+	if len(stack) > 0 {
+		return stack[0]
+	}
+	return profile.Frame{}
+}
+
+func isInternal(function string) bool {
+	for _, prefix := range []string{"runtime.", "sync.", "internal/sync.", "sync/atomic."} {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCapture reports whether the stack is Collect itself waiting out the window.
+func isCapture(stack []profile.Frame) bool {
+	for _, f := range stack {
+		if strings.HasSuffix(f.Function, "/internal/contention.Collect") {
+			return true
+		}
+	}
+	return false
+}
+
+func stackKey(stack []profile.Frame) string {
+	var b strings.Builder
+	for _, f := range stack {
+		fmt.Fprintf(&b, "%s %s:%d\n", f.Function, f.File, f.Line)
+	}
+	return b.String()
+}
@@ -0,0 +1,195 @@
+package contention
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/profile"
+)
+
+var sampleTypes = []profile.ValueType{{Type: "contentions", Unit: "count"}, {Type: "delay", Unit: "nanoseconds"}}
+
+var (
+	lockSlow   = profile.Frame{Function: "sync.(*Mutex).lockSlow", File: "/go/src/sync/mutex.go", Line: 171}
+	unlockSlow = profile.Frame{Function: "internal/sync.(*Mutex).unlockSlow", File: "/go/src/internal/sync/mutex.go", Line: 204}
+	semrelease = profile.Frame{Function: "runtime.semrelease1", File: "/go/src/runtime/sema.go", Line: 204}
+	chanrecv   = profile.Frame{Function: "runtime.chanrecv1", File: "/go/src/runtime/chan.go", Line: 489}
+	store      = profile.Frame{Function: "main.(*Store).Put", File: "/src/store.go", Line: 42}
+	storeGet   = profile.Frame{Function: "main.(*Store).Get", File: "/src/store.go", Line: 30}
+	worker     = profile.Frame{Function: "main.worker", File: "/src/worker.go", Line: 12}
+	handler    = profile.Frame{Function: "main.handler", File: "/src/http.go", Line: 7}
+	capture    = profile.Frame{Function: "github.com/Aldiwildan77/inspectd/internal/contention.Collect", File: "/src/contention.go", Line: 90}
+)
+
+func sample(count, delay int64, stack ...profile.Frame) profile.Sample {
+	return profile.Sample{Stack: stack, Values: []int64{count, delay}}
+}
+
+func contentionProfile(samples ...profile.Sample) *profile.Profile {
+	return &profile.Profile{SampleTypes: sampleTypes, Samples: samples}
+}
+
+func TestTopCallSites(t *testing.T) {
+	ms := int64(time.Millisecond)
+	tests := []struct {
+		name          string
+		before, after *profile.Profile
+		top           int
+		want          []CallSite
+	}{
+		{
+			name:   "no samples",
+			before: contentionProfile(),
+			after:  contentionProfile(),
+			top:    DefaultTop,
+			want:   []CallSite{},
+		},
+		{
+			name:   "no sample types",
+			before: &profile.Profile{},
+			after:  &profile.Profile{Samples: []profile.Sample{sample(1, ms, store)}},
+			top:    DefaultTop,
+			want:   []CallSite{},
+		},
+		{
+			name:   "delta against the baseline",
+			before: contentionProfile(sample(10, 100*ms, unlockSlow, store, worker), sample(5, 50*ms, chanrecv, worker)),
+			after:  contentionProfile(sample(14, 300*ms, unlockSlow, store, worker), sample(5, 50*ms, chanrecv, worker)),
+			top:    DefaultTop,
+			want: []CallSite{
+				{Function: store.Function, File: store.File, Line: store.Line, Count: 4, Wait: 0.2, Frames: []profile.Frame{unlockSlow, store, worker}},
+			},
+		},
+		{
+			name:   "stacks new in the window",
+			before: contentionProfile(),
+			after:  contentionProfile(sample(2, 20*ms, semrelease, unlockSlow, storeGet, handler)),
+			top:    DefaultTop,
+			want: []CallSite{
+				{Function: storeGet.Function, File: storeGet.File, Line: storeGet.Line, Count: 2, Wait: 0.02, Frames: []profile.Frame{semrelease, unlockSlow, storeGet, handler}},
+			},
+		},
+		{
+			name:   "stacks through one call site are aggregated",
+			before: contentionProfile(),
+			after: contentionProfile(
+				sample(1, 10*ms, lockSlow, store, worker),
+				sample(3, 90*ms, lockSlow, store, handler),
+			),
+			top: DefaultTop,
+			want: []CallSite{
+				{Function: store.Function, File: store.File, Line: store.Line, Count: 4, Wait: 0.1, Frames: []profile.Frame{lockSlow, store, handler}},
+			},
+		},
+		{
+			name:   "fully internal stack keeps its leaf",
+			before: contentionProfile(),
+			after:  contentionProfile(sample(1, 5*ms, semrelease, unlockSlow)),
+			top:    DefaultTop,
+			want: []CallSite{
+				{Function: semrelease.Function, File: semrelease.File, Line: semrelease.Line, Count: 1, Wait: 0.005, Frames: []profile.Frame{semrelease, unlockSlow}},
+			},
+		},
+		{
+			name:   "the capture's own wait is excluded",
+			before: contentionProfile(),
+			after:  contentionProfile(sample(1, 1000*ms, chanrecv, capture)),
+			top:    DefaultTop,
+			want:   []CallSite{},
+		},
+		{
+			name:   "ranked by wait then count and truncated",
+			before: contentionProfile(),
+			after: contentionProfile(
+				sample(1, 10*ms, lockSlow, store),
+				sample(5, 10*ms, lockSlow, storeGet),
+				sample(2, 50*ms, chanrecv, worker),
+			),
+			top: 2,
+			want: []CallSite{
+				{Function: worker.Function, File: worker.File, Line: worker.Line, Count: 2, Wait: 0.05, Frames: []profile.Frame{chanrecv, worker}},
+				{Function: storeGet.Function, File: storeGet.File, Line: storeGet.Line, Count: 5, Wait: 0.01, Frames: []profile.Frame{lockSlow, storeGet}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := topCallSites(tt.before, tt.after, time.Second, tt.top)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("topCallSites() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopCallSitesImplausibleDelay(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after *profile.Profile
+	}{
+		{
+			// Mutex delays recorded while block profiling was also on: about 4350 s for
+			// a handful of contentions in a 1 s window.
+			name:   "delay beyond the window",
+			before: contentionProfile(sample(100, 2*int64(time.Second), unlockSlow, store)),
+			after:  contentionProfile(sample(104, 9_150_000_000_000, unlockSlow, store)),
+		},
+		{
+			name:   "delay without contentions",
+			before: contentionProfile(sample(3, int64(time.Millisecond), unlockSlow, store)),
+			after:  contentionProfile(sample(3, int64(time.Second), unlockSlow, store)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := topCallSites(tt.before, tt.after, time.Second, DefaultTop)
+			if err == nil || !strings.Contains(err.Error(), "implausible delay") || !strings.Contains(err.Error(), store.Function) {
+				t.Errorf("topCallSites() error = %v, want an implausible delay at %s", err, store.Function)
+			}
+		})
+	}
+}
+
+func TestCallSite(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack []profile.Frame
+		want  profile.Frame
+	}{
+		{"first frame outside runtime and sync", []profile.Frame{semrelease, unlockSlow, lockSlow, store, worker}, store},
+		{"user leaf", []profile.Frame{worker, handler}, worker},
+		{"all internal", []profile.Frame{semrelease, unlockSlow}, semrelease},
+		{"empty", nil, profile.Frame{}},
+		{"sync/atomic", []profile.Frame{{Function: "sync/atomic.(*Int64).Add"}, handler}, handler},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callSite(tt.stack); got != tt.want {
+				t.Errorf("callSite() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCapture(t *testing.T) {
+	tests := []struct {
+		stack []profile.Frame
+		want  bool
+	}{
+		{[]profile.Frame{chanrecv, capture}, true},
+		{[]profile.Frame{chanrecv, {Function: "github.com/Aldiwildan77/inspectd/internal/contention.record"}, capture}, true},
+		{[]profile.Frame{chanrecv, worker}, false},
+		{[]profile.Frame{{Function: "example.com/other/contention.Collect"}}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isCapture(tt.stack); got != tt.want {
+			t.Errorf("isCapture(%v) = %v, want %v", tt.stack, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package exectrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/trace"
+	"time"
+)
+
+const (
+	DefaultDuration = 5 * time.Second
+
+	// MaxDuration bounds the capture; traces grow by several MB per second in busy processes.
+	MaxDuration = time.Minute
+)
+
+type Capture struct {
+	Duration float64 `json:"duration_seconds"`
+	Trace    []byte  `json:"trace"`
+}
+
+// Collect records an execution trace of the current process for the duration.
+// Cancelling ctx stops the trace early and returns ctx.Err().
+func Collect(ctx context.Context, duration time.Duration) (*Capture, error) {
+	if duration <= 0 {
+		duration = DefaultDuration
+	}
+	if duration > MaxDuration {
+		return nil, fmt.Errorf("duration %s exceeds the maximum of %s", duration, MaxDuration)
+	}
+
+	var buf bytes.Buffer
+	start := time.Now()
+	if err := trace.Start(&buf); err != nil {
+		return nil, fmt.Errorf("failed to start trace: %w", err)
+	}
+	timer := time.NewTimer(duration)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	trace.Stop()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Capture{
+		Duration: time.Since(start).Seconds(),
+		Trace:    buf.Bytes(),
+	}, nil
+}
+
+func CollectJSON(ctx context.Context, duration time.Duration) ([]byte, error) {
+	capture, err := Collect(ctx, duration)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(capture)
+}
@@ -0,0 +1,428 @@
+package exectrace
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultTop = 20
+
+type Summary struct {
+	Output         string           `json:"output"`
+	Duration       float64          `json:"duration_seconds"`
+	GoroutineCount int              `json:"goroutine_count"`
+	Totals         StateTimes       `json:"totals"`
+	Goroutines     []GoroutineTimes `json:"goroutines"`
+	GC             GCSummary        `json:"gc"`
+	STW            STWSummary       `json:"stw"`
+}
+
+// StateTimes is the time spent in each goroutine state during the trace.
+// Blocked covers every wait (channels, locks, I/O, sleep), broken down by reason in BlockedBy.
+type StateTimes struct {
+	Running   float64            `json:"running_seconds"`
+	Runnable  float64            `json:"runnable_seconds"`
+	Blocked   float64            `json:"blocked_seconds"`
+	Syscall   float64            `json:"syscall_seconds"`
+	BlockedBy map[string]float64 `json:"blocked_by,omitempty"`
+}
+
+type GoroutineTimes struct {
+	ID int64 `json:"id"`
+	StateTimes
+}
+
+type GCSummary struct {
+	Cycles int       `json:"cycles"`
+	Phases []GCPhase `json:"phases"`
+}
+
+// GCPhase aggregates every occurrence of one GC range, such as the concurrent
+// mark phase, mark assists or incremental sweeping.
+type GCPhase struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Total float64 `json:"total_seconds"`
+	Max   float64 `json:"max_seconds"`
+}
+
+type STWSummary struct {
+	Count  int        `json:"count"`
+	Total  float64    `json:"total_seconds"`
+	Max    float64    `json:"max_seconds"`
+	Events []STWEvent `json:"events"`
+}
+
+type STWEvent struct {
+	Reason   string  `json:"reason"`
+	Start    float64 `json:"start_offset_seconds"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// Summarize parses the trace file at path with `go tool trace -d=parsed`,
+// which uses the toolchain's own trace parser, and summarizes it. The go
+// command must be in PATH and at least as new as the traced program's Go.
+// The -d=parsed text is a debug format without stability guarantees, so event
+// lines that do not have the expected shape fail the summary with the line
+// instead of producing silently wrong numbers.
+func Summarize(ctx context.Context, path string, top int) (*Summary, error) {
+	if top <= 0 {
+		top = DefaultTop
+	}
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		return nil, fmt.Errorf("summarizing traces requires the go command in PATH: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, goTool, "tool", "trace", "-d=parsed", path)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run go tool trace: %w", err)
+	}
+
+	summary, parseErr := summarize(stdout, top)
+	if parseErr != nil {
+		// Drain the pipe so the tool can exit before Wait.
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("go tool trace failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	summary.Output = path
+	return summary, nil
+}
+
+type goroutineState struct {
+	state  string
+	reason string
+	since  int64
+	times  map[string]int64
+	waits  map[string]int64
+}
+
+type openRange struct {
+	name  string
+	start int64
+}
+
+type rangeTotals struct {
+	count      int
+	total, max int64
+}
+
+type traceParser struct {
+	start, end int64
+	goroutines map[int64]*goroutineState
+	ranges     map[string][]openRange
+	gcRanges   map[string]*rangeTotals
+	gcCycles   int
+	stw        []STWEvent
+}
+
+func summarize(r io.Reader, top int) (*Summary, error) {
+	p := &traceParser{
+		start:      -1,
+		goroutines: make(map[int64]*goroutineState),
+		ranges:     make(map[string][]openRange),
+		gcRanges:   make(map[string]*rangeTotals),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	lineNumber, otherLines := 0, 0
+	for scanner.Scan() {
+		lineNumber++
+		// Event lines start with the M= field; indented lines are stacks and attributes.
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "M=") {
+			if strings.TrimSpace(line) != "" {
+				otherLines++
+			}
+			continue
+		}
+		if err := p.event(line); err != nil {
+			return nil, fmt.Errorf("unexpected go tool trace output on line %d, the -d=parsed format may have changed in this Go version: %w: %q", lineNumber, err, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read parsed trace: %w", err)
+	}
+	if p.start < 0 {
+		if otherLines > 0 {
+			return nil, errors.New("no events found in go tool trace output, the -d=parsed format may have changed in this Go version")
+		}
+		return nil, errors.New("trace contains no events")
+	}
+
+	return p.finish(top), nil
+}
+
+func (p *traceParser) event(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) < 5 || !strings.HasPrefix(fields[4], "Time=") {
+		return errors.New("missing event time")
+	}
+	t, err := strconv.ParseInt(strings.TrimPrefix(fields[4], "Time="), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid event time: %w", err)
+	}
+	if p.start < 0 || t < p.start {
+		p.start = t
+	}
+	if t > p.end {
+		p.end = t
+	}
+
+	switch fields[3] {
+	case "StateTransition":
+		return p.transition(t, line)
+	case "RangeBegin":
+		name, ok := quoted(line, "Name=")
+		if !ok {
+			return errors.New("missing range name")
+		}
+		key := name + "|" + field(line, "Scope=")
+		p.ranges[key] = append(p.ranges[key], openRange{name: name, start: t})
+	case "RangeEnd":
+		name, ok := quoted(line, "Name=")
+		if !ok {
+			return errors.New("missing range name")
+		}
+		key := name + "|" + field(line, "Scope=")
+		start := p.start // Ranges already active when the trace started
+		if open := p.ranges[key]; len(open) > 0 {
+			start = open[len(open)-1].start
+			p.ranges[key] = open[:len(open)-1]
+		}
+		p.closeRange(name, start, t)
+	}
+	// Other event kinds (metrics, labels, logs, ...) do not contribute to the summary.
+	return nil
+}
+
+func (p *traceParser) transition(t int64, line string) error {
+	goID := field(line, "GoID=")
+	if goID == "" {
+		if field(line, "ProcID=") == "" {
+			return errors.New("state transition has neither GoID nor ProcID")
+		}
+		return nil // Proc transitions
+	}
+	id, err := strconv.ParseInt(goID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid goroutine ID: %w", err)
+	}
+	from, to, ok := strings.Cut(transitionField(line), "->")
+	if !ok {
+		return errors.New("missing From->To states")
+	}
+
+	g, ok := p.goroutines[id]
+	if !ok {
+		g = &goroutineState{times: make(map[string]int64), waits: make(map[string]int64)}
+		p.goroutines[id] = g
+		// A goroutine first seen leaving a known state has been in it since the trace began.
+		if from != "Undetermined" && from != "NotExist" {
+			g.state, g.since = from, p.start
+		}
+	}
+	g.leave(t)
+	g.state, g.since = to, t
+	g.reason, _ = quoted(line, "Reason=")
+	return nil
+}
+
+func (g *goroutineState) leave(t int64) {
+	if g.state == "" {
+		return
+	}
+	elapsed := t - g.since
+	g.times[g.state] += elapsed
+	if g.state == "Waiting" {
+		reason := g.reason
+		if reason == "" {
+			reason = "unknown"
+		}
+		g.waits[reason] += elapsed
+	}
+}
+
+func (p *traceParser) closeRange(name string, start, end int64) {
+	elapsed := end - start
+	if reason, ok := strings.CutPrefix(name, "stop-the-world ("); ok {
+		p.stw = append(p.stw, STWEvent{
+			Reason:   strings.TrimSuffix(reason, ")"),
+			Start:    time.Duration(start - p.start).Seconds(),
+			Duration: time.Duration(elapsed).Seconds(),
+		})
+	}
+	if !strings.HasPrefix(name, "GC ") && !strings.Contains(name, "(GC ") {
+		return
+	}
+	if name == "GC concurrent mark phase" {
+		p.gcCycles++
+	}
+	totals, ok := p.gcRanges[name]
+	if !ok {
+		totals = &rangeTotals{}
+		p.gcRanges[name] = totals
+	}
+	totals.count++
+	totals.total += elapsed
+	totals.max = max(totals.max, elapsed)
+}
+
+func (p *traceParser) finish(top int) *Summary {
+	// Close ranges still open at the end of the trace.
+	for _, open := range p.ranges {
+		for _, r := range open {
+			p.closeRange(r.name, r.start, p.end)
+		}
+	}
+
+	summary := &Summary{
+		Duration:       time.Duration(p.end - p.start).Seconds(),
+		GoroutineCount: len(p.goroutines),
+		Goroutines:     make([]GoroutineTimes, 0, len(p.goroutines)),
+		GC:             GCSummary{Cycles: p.gcCycles, Phases: make([]GCPhase, 0, len(p.gcRanges))},
+		STW:            STWSummary{Count: len(p.stw), Events: p.stw},
+	}
+	if summary.STW.Events == nil {
+		summary.STW.Events = []STWEvent{}
+	}
+
+	totalWaits := make(map[string]int64)
+	var totals [4]int64
+	for id, g := range p.goroutines {
+		g.leave(p.end)
+		times := stateTimes(g.times, g.waits)
+		summary.Goroutines = append(summary.Goroutines, GoroutineTimes{ID: id, StateTimes: times})
+		for i, state := range []string{"Running", "Runnable", "Waiting", "Syscall"} {
+			totals[i] += g.times[state]
+		}
+		for reason, d := range g.waits {
+			totalWaits[reason] += d
+		}
+	}
+	summary.Totals = stateTimes(map[string]int64{
+		"Running": totals[0], "Runnable": totals[1], "Waiting": totals[2], "Syscall": totals[3],
+	}, totalWaits)
+
+	// Goroutines that ran the most first; ties by ID keep the output stable.
+	sort.Slice(summary.Goroutines, func(i, j int) bool {
+		a, b := summary.Goroutines[i], summary.Goroutines[j]
+		if a.Running != b.Running {
+			return a.Running > b.Running
+		}
+		return a.ID < b.ID
+	})
+	if len(summary.Goroutines) > top {
+		summary.Goroutines = summary.Goroutines[:top]
+	}
+
+	for name, totals := range p.gcRanges {
+		summary.GC.Phases = append(summary.GC.Phases, GCPhase{
+			Name:  name,
+			Count: totals.count,
+			Total: time.Duration(totals.total).Seconds(),
+			Max:   time.Duration(totals.max).Seconds(),
+		})
+	}
+	sort.Slice(summary.GC.Phases, func(i, j int) bool {
+		return summary.GC.Phases[i].Name < summary.GC.Phases[j].Name
+	})
+
+	for _, event := range p.stw {
+		summary.STW.Total += event.Duration
+		summary.STW.Max = max(summary.STW.Max, event.Duration)
+	}
+
+	// Keep the longest pauses, listed in the order they happened.
+	sort.Slice(summary.STW.Events, func(i, j int) bool {
+		return summary.STW.Events[i].Duration > summary.STW.Events[j].Duration
+	})
+	if len(summary.STW.Events) > top {
+		summary.STW.Events = summary.STW.Events[:top]
+	}
+	sort.Slice(summary.STW.Events, func(i, j int) bool {
+		return summary.STW.Events[i].Start < summary.STW.Events[j].Start
+	})
+
+	return summary
+}
+
+func stateTimes(times, waits map[string]int64) StateTimes {
+	st := StateTimes{
+		Running:  time.Duration(times["Running"]).Seconds(),
+		Runnable: time.Duration(times["Runnable"]).Seconds(),
+		Blocked:  time.Duration(times["Waiting"]).Seconds(),
+		Syscall:  time.Duration(times["Syscall"]).Seconds(),
+	}
+	if len(waits) > 0 {
+		st.BlockedBy = make(map[string]float64, len(waits))
+		for reason, d := range waits {
+			st.BlockedBy[reason] = time.Duration(d).Seconds()
+		}
+	}
+	return st
+}
+
+// field returns the unquoted value following key, e.g. field(line, "GoID=").
+func field(line, key string) string {
+	i := strings.Index(line, " "+key)
+	if i < 0 {
+		return ""
+	}
+	value := line[i+1+len(key):]
+	if end := strings.IndexByte(value, ' '); end >= 0 {
+		value = value[:end]
+	}
+	return value
+}
+
+// quoted returns the Go-quoted string value following key, e.g. Name="...",
+// and whether the line has a well-formed value for key.
+func quoted(line, key string) (string, bool) {
+	i := strings.Index(line, " "+key)
+	if i < 0 {
+		return "", false
+	}
+	value := line[i+1+len(key):]
+	prefix, err := strconv.QuotedPrefix(value)
+	if err != nil {
+		return "", false
+	}
+	s, err := strconv.Unquote(prefix)
+	if err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// transitionField returns the From->To field of a state transition.
+func transitionField(line string) string {
+	for _, f := range strings.Fields(line) {
+		if strings.Contains(f, "->") && !strings.Contains(f, "=") {
+			return f
+		}
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package exectrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/schema"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+// testdata/parsed.txt is `go tool trace -d=parsed` output recorded from a
+// short trace of a program that blocks on channels, sleeps and runs a GC.
+func TestSummarizeGolden(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "parsed.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	summary, err := summarize(f, DefaultTop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "parsed.golden.json")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("summary does not match %s (run go test -update to rewrite it):\n%s", golden, got)
+	}
+	if err := schema.Of(Summary{}).Validate(got); err != nil {
+		t.Errorf("summary does not match schema: %v", err)
+	}
+}
+
+func TestSummarizeTransitions(t *testing.T) {
+	input := strings.Join([]string{
+		`M=1 P=0 G=-1 StateTransition Time=1000 ProcID=0 Undetermined->Running Reason=""`,
+		`M=1 P=0 G=-1 StateTransition Time=1000 GoID=1 Undetermined->Running Reason=""`,
+		`Stack=`,
+		"\tmain.main @ 0x1",
+		`M=1 P=0 G=1 StateTransition Time=3000 GoID=1 Running->Waiting Reason="chan receive"`,
+		`M=1 P=0 G=-1 StateTransition Time=3000 GoID=2 Runnable->Running Reason=""`,
+		`M=1 P=0 G=2 RangeBegin Time=3500 Name="GC concurrent mark phase" Scope=None`,
+		`M=1 P=0 G=2 RangeBegin Time=4000 Name="stop-the-world (GC mark termination)" Scope=Goroutine(2)`,
+		`M=1 P=0 G=2 RangeEnd Time=4500 Name="stop-the-world (GC mark termination)" Scope=Goroutine(2) Attributes=[]`,
+		`M=1 P=0 G=2 RangeEnd Time=5000 Name="GC concurrent mark phase" Scope=None Attributes=[]`,
+		`M=1 P=0 G=2 Metric Time=5500 Name="/gc/heap/goal:bytes" Value=Value{Uint64(4194304)}`,
+		`M=1 P=0 G=2 StateTransition Time=6000 GoID=1 Waiting->Runnable Reason=""`,
+		``,
+	}, "\n")
+
+	summary, err := summarize(strings.NewReader(input), DefaultTop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.GoroutineCount != 2 {
+		t.Errorf("GoroutineCount = %d, want 2", summary.GoroutineCount)
+	}
+	if want := 5000 * time.Nanosecond; summary.Duration != want.Seconds() {
+		t.Errorf("Duration = %v, want %v", summary.Duration, want.Seconds())
+	}
+	if want := (3 * time.Microsecond).Seconds(); summary.Totals.BlockedBy["chan receive"] != want {
+		t.Errorf("BlockedBy[chan receive] = %v, want %v", summary.Totals.BlockedBy["chan receive"], want)
+	}
+	if summary.GC.Cycles != 1 {
+		t.Errorf("GC.Cycles = %d, want 1", summary.GC.Cycles)
+	}
+	if summary.STW.Count != 1 || summary.STW.Events[0].Reason != "GC mark termination" {
+		t.Errorf("STW = %+v, want one GC mark termination pause", summary.STW)
+	}
+}
+
+func TestSummarizeUnexpectedLines(t *testing.T) {
+	valid := `M=1 P=0 G=-1 StateTransition Time=1000 GoID=1 Undetermined->Running Reason=""`
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"missing time", `M=1 P=0 G=1 StateTransition GoID=1`, "missing event time"},
+		{"invalid time", `M=1 P=0 G=1 StateTransition Time=soon GoID=1 Running->Waiting`, "invalid event time"},
+		{"no goroutine or proc", `M=1 P=0 G=1 StateTransition Time=2000 ThreadID=1 Running->Waiting`, "neither GoID nor ProcID"},
+		{"invalid goroutine", `M=1 P=0 G=1 StateTransition Time=2000 GoID=one Running->Waiting`, "invalid goroutine ID"},
+		{"no states", `M=1 P=0 G=1 StateTransition Time=2000 GoID=1 Running Reason=""`, "missing From->To states"},
+		{"unquoted range name", `M=1 P=0 G=1 RangeBegin Time=2000 Name=GC Scope=None`, "missing range name"},
+		{"range without name", `M=1 P=0 G=1 RangeEnd Time=2000 Scope=None Attributes=[]`, "missing range name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := summarize(strings.NewReader(valid+"\n"+tt.line+"\n"), DefaultTop)
+			if err == nil {
+				t.Fatal("summarize succeeded, want an error")
+			}
+			for _, want := range []string{"line 2", tt.want, strconv.Quote(tt.line)} {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeNoEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", "trace contains no events"},
+		{"unrecognized", "Event 1 at 1000: GoStart g=1\n", "format may have changed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := summarize(strings.NewReader(tt.input), DefaultTop)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("summarize() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestSummarizeLive checks that the installed toolchain's -d=parsed output
+// still parses, which is what breaks first when the format changes.
+func TestSummarizeLive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("captures a trace")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not in PATH")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ch := make(chan int)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+			}
+			go func() { ch <- 1 }()
+			<-ch
+		}
+	}()
+	capture, err := Collect(context.Background(), 100*time.Millisecond)
+	close(done)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "live.trace")
+	if err := os.WriteFile(path, capture.Trace, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := Summarize(context.Background(), path, DefaultTop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.GoroutineCount == 0 {
+		t.Error("summary has no goroutines")
+	}
+	if summary.Output != path {
+		t.Errorf("Output = %q, want %q", summary.Output, path)
+	}
+}
@@ -0,0 +1,147 @@
+package fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Parse splits a comma-separated list of dotted paths, such as
+// "memory.heap_in_use_bytes,goroutines.total_count", into path elements.
+func Parse(list string) ([][]string, error) {
+	var paths [][]string
+	for _, field := range strings.Split(list, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		path := strings.Split(field, ".")
+		for _, elem := range path {
+			if elem == "" {
+				return nil, fmt.Errorf("invalid field path: %s", field)
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// Sections returns the distinct top-level fields of paths in order.
+func Sections(paths [][]string) []string {
+	var sections []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if !seen[path[0]] {
+			seen[path[0]] = true
+			sections = append(sections, path[0])
+		}
+	}
+	return sections
+}
+
+// tree holds the requested paths by element; an empty tree selects the whole value.
+type tree map[string]tree
+
+// Project reduces the JSON document in data to the fields at paths. Paths
+// apply to every element of arrays along the way; fields absent from the
+// document are left out. Fields keep their order in the document.
+func Project(data []byte, paths [][]string) ([]byte, error) {
+	if len(paths) == 0 {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := project(&buf, data, newTree(paths)); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	var out bytes.Buffer
+	if err := json.Compact(&out, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+func newTree(paths [][]string) tree {
+	root := tree{}
+	for _, path := range paths {
+		node := root
+		for i, elem := range path {
+			child, ok := node[elem]
+			if ok && len(child) == 0 {
+				break // A shorter path already selects the whole value
+			}
+			if !ok || i == len(path)-1 {
+				child = tree{}
+				node[elem] = child
+			}
+			node = child
+		}
+	}
+	return root
+}
+
+// project writes the parts of the JSON value selected by t to buf. Selected
+// values are copied as they are, so numbers keep their precision.
+func project(buf *bytes.Buffer, value []byte, t tree) error {
+	value = bytes.TrimSpace(value)
+	if len(t) == 0 || len(value) == 0 || value[0] != '{' && value[0] != '[' {
+		buf.Write(value)
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(value))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if value[0] == '[' {
+		buf.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			var elem json.RawMessage
+			if err := dec.Decode(&elem); err != nil {
+				return err
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := project(buf, elem, t); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+
+	buf.WriteByte('{')
+	written := 0
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var field json.RawMessage
+		if err := dec.Decode(&field); err != nil {
+			return err
+		}
+		key := tok.(string)
+		child, ok := t[key]
+		if !ok {
+			continue
+		}
+		if written > 0 {
+			buf.WriteByte(',')
+		}
+		written++
+		name, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		if err := project(buf, field, child); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
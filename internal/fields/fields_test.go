@@ -0,0 +1,114 @@
+package fields
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    [][]string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{" , ", nil, false},
+		{"memory", [][]string{{"memory"}}, false},
+		{"memory.heap_in_use_bytes, goroutines.total_count", [][]string{{"memory", "heap_in_use_bytes"}, {"goroutines", "total_count"}}, false},
+		{"groups.count,,groups.state", [][]string{{"groups", "count"}, {"groups", "state"}}, false},
+		{"a.b.c.d", [][]string{{"a", "b", "c", "d"}}, false},
+		{"memory..heap", nil, true},
+		{".memory", nil, true},
+		{"memory.", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := Parse(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse(%q) error = %v, wantErr %v", tt.list, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %q, want %q", tt.list, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSections(t *testing.T) {
+	paths := [][]string{{"memory", "heap_in_use_bytes"}, {"runtime"}, {"memory", "gc_cycles"}, {"goroutines", "total_count"}}
+	if got, want := Sections(paths), []string{"memory", "runtime", "goroutines"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sections() = %v, want %v", got, want)
+	}
+	if got := Sections(nil); got != nil {
+		t.Errorf("Sections(nil) = %v, want nil", got)
+	}
+}
+
+const document = `{
+	"runtime": {"go_version": "go1.25.0", "num_cpu": 8},
+	"memory": {"heap_in_use_bytes": 4194304, "gc_cycles": 12, "last_gc_unix_nano": 1735732800123456789, "pause_seconds": [0.001, 0.0025]},
+	"groups": [
+		{"state": "running", "count": 2, "frames": [{"function": "main.a", "line": 1}, {"function": "main.b", "line": 2}]},
+		{"state": "chan receive", "count": 40, "frames": []}
+	],
+	"os": null
+}`
+
+func TestProject(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths string
+		want  string
+	}{
+		{"no paths", "", document},
+		{"section", "runtime", `{"runtime":{"go_version":"go1.25.0","num_cpu":8}}`},
+		{"nested path", "memory.gc_cycles", `{"memory":{"gc_cycles":12}}`},
+		{"document order", "memory.gc_cycles,runtime.num_cpu,memory.heap_in_use_bytes", `{"runtime":{"num_cpu":8},"memory":{"heap_in_use_bytes":4194304,"gc_cycles":12}}`},
+		{"large integers keep their precision", "memory.last_gc_unix_nano", `{"memory":{"last_gc_unix_nano":1735732800123456789}}`},
+		{"array of scalars", "memory.pause_seconds", `{"memory":{"pause_seconds":[0.001,0.0025]}}`},
+		{"array projection", "groups.count", `{"groups":[{"count":2},{"count":40}]}`},
+		{"nested arrays", "groups.frames.function", `{"groups":[{"frames":[{"function":"main.a"},{"function":"main.b"}]},{"frames":[]}]}`},
+		{"duplicate paths", "groups.count,groups.count", `{"groups":[{"count":2},{"count":40}]}`},
+		{"shorter path first", "memory,memory.gc_cycles", `{"memory":{"heap_in_use_bytes":4194304,"gc_cycles":12,"last_gc_unix_nano":1735732800123456789,"pause_seconds":[0.001,0.0025]}}`},
+		{"shorter path last", "memory.gc_cycles,memory", `{"memory":{"heap_in_use_bytes":4194304,"gc_cycles":12,"last_gc_unix_nano":1735732800123456789,"pause_seconds":[0.001,0.0025]}}`},
+		{"unknown field", "memory.bogus", `{"memory":{}}`},
+		{"unknown section", "bogus", `{}`},
+		{"null section", "os.pid", `{"os":null}`},
+		{"path through a scalar", "runtime.num_cpu.bits", `{"runtime":{"num_cpu":8}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := Parse(tt.paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Project([]byte(document), paths)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Project(%s) =\n%s\nwant\n%s", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectTopLevelArray(t *testing.T) {
+	data := `[{"name":"/gc/cycles/total:gc-cycles","kind":"counter","value":12},{"name":"/sched/goroutines:goroutines","kind":"gauge","value":9}]`
+	got, err := Project([]byte(data), [][]string{{"value"}, {"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"name":"/gc/cycles/total:gc-cycles","value":12},{"name":"/sched/goroutines:goroutines","value":9}]`; string(got) != want {
+		t.Errorf("Project() = %s, want %s", got, want)
+	}
+}
+
+func TestProjectInvalid(t *testing.T) {
+	for _, data := range []string{`{"memory":`, `{"memory":{"a":1}`, `[1,2`, `{"a":tru}`} {
+		if _, err := Project([]byte(data), [][]string{{"memory", "a"}}); err == nil || !strings.Contains(err.Error(), "failed to decode output") {
+			t.Errorf("Project(%s) error = %v, want a decode error", data, err)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package format
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Output formats. Every format is produced from the JSON output of a command.
+const (
+	JSON       = "json"
+	JSONPretty = "json-pretty"
+	NDJSON     = "ndjson"
+	Logfmt     = "logfmt"
+	Prometheus = "prometheus"
+	Table      = "table"
+)
+
+// Names lists the supported formats, the default first.
+func Names() []string {
+	return []string{JSON, JSONPretty, NDJSON, Logfmt, Prometheus, Table}
+}
+
+// Valid reports whether format is a supported format name.
+func Valid(format string) bool {
+	for _, name := range Names() {
+		if name == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Write encodes the JSON output of the named command to w in format.
+func Write(w io.Writer, format, command string, data []byte) error {
+	bw := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case JSON:
+		err = writeJSON(bw, data)
+	case JSONPretty:
+		err = writeJSONPretty(bw, data)
+	case NDJSON:
+		err = writeNDJSON(bw, data)
+	case Logfmt:
+		err = writeLogfmt(bw, data)
+	case Prometheus:
+		err = writePrometheus(bw, command, data)
+	case Table:
+		err = writeTable(bw, data)
+	default:
+		err = fmt.Errorf("unknown format: %s", format)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func writeJSON(w *bufio.Writer, data []byte) error {
+	w.Write(data)
+	return w.WriteByte('\n')
+}
+
+func writeJSONPretty(w *bufio.Writer, data []byte) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return fmt.Errorf("failed to indent output: %w", err)
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeNDJSON writes one line per element of a top-level array, or the whole
+// document on one line otherwise.
+func writeNDJSON(w *bufio.Writer, data []byte) error {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return writeJSON(w, data)
+	}
+	for _, elem := range elems {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, elem); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		if _, err := buf.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLogfmt writes dotted-path key=value pairs, one line per element of a
+// top-level array or one line for the whole document.
+func writeLogfmt(w *bufio.Writer, data []byte) error {
+	value, err := decode(data)
+	if err != nil {
+		return err
+	}
+
+	records := []any{value}
+	if elems, ok := value.([]any); ok {
+		records = elems
+	}
+	for _, record := range records {
+		first := true
+		walk(record, nil, func(path []string, leaf any) {
+			if !first {
+				w.WriteByte(' ')
+			}
+			first = false
+			key := logfmtKey(strings.Join(path, "."))
+			if key == "" {
+				key = "value"
+			}
+			w.WriteString(key)
+			w.WriteByte('=')
+			w.WriteString(logfmtValue(leaf))
+		})
+		w.WriteByte('\n')
+	}
+	return nil
+}
+
+// logfmtKey replaces the characters that would end a logfmt key early.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func logfmtValue(leaf any) string {
+	s := scalarString(leaf)
+	if _, ok := leaf.(string); ok && (s == "" || strings.ContainsAny(s, " =\"\\") || strings.IndexFunc(s, unicode.IsControl) >= 0) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// field is an object member; object keeps members in document order so
+// encoders list fields in the same order as the JSON output.
+type field struct {
+	key   string
+	value any
+}
+
+type object []field
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(f.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decode parses JSON into objects, []any and scalars (json.Number, string, bool, nil).
+func decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode output: %w", err)
+	}
+	return value, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		obj := object{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, field{key: keyTok.(string), value: value})
+		}
+		_, err = dec.Token()
+		return obj, err
+	default:
+		arr := []any{}
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		_, err = dec.Token()
+		return arr, err
+	}
+}
+
+// walk calls fn for every scalar in value with its path; array elements are
+// keyed by index.
+func walk(value any, path []string, fn func(path []string, leaf any)) {
+	switch v := value.(type) {
+	case object:
+		for _, f := range v {
+			walk(f.value, append(path, f.key), fn)
+		}
+	case []any:
+		for i, elem := range v {
+			walk(elem, append(path, strconv.Itoa(i)), fn)
+		}
+	default:
+		fn(path, v)
+	}
+}
+
+func scalarString(leaf any) string {
+	switch v := leaf.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
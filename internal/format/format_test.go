@@ -0,0 +1,165 @@
+package format
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata golden files")
+
+// Each testdata/<name>.json is encoded in every format and compared with
+// testdata/<name>.golden.<format>. object.json covers nested objects, nulls, label
+// escaping and names that need sanitising; rows.json is a top-level array
+// such as the metrics command prints.
+func TestWriteGolden(t *testing.T) {
+	for _, name := range []string{"object", "rows"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = bytes.TrimSpace(data)
+
+		for _, format := range Names() {
+			t.Run(name+"/"+format, func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := Write(&buf, format, "profile heap", data); err != nil {
+					t.Fatal(err)
+				}
+				got := buf.Bytes()
+
+				golden := filepath.Join("testdata", name+".golden."+format)
+				if *update {
+					if err := os.WriteFile(golden, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("output does not match %s (run go test -update to rewrite it):\n%s", golden, got)
+				}
+			})
+		}
+	}
+}
+
+func TestWriteScalars(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+		want   string
+	}{
+		{Logfmt, `42`, "value=42\n"},
+		{Logfmt, `"two words"`, "value=\"two words\"\n"},
+		{Logfmt, `[{"a":1},{"a":2}]`, "a=1\na=2\n"},
+		{Logfmt, `{"a":{"b":[true,null]}}`, "a.b.0=true a.b.1=\n"},
+		{Prometheus, `42`, "# TYPE inspectd_uptime untyped\ninspectd_uptime 42\n"},
+		{Prometheus, `{"go_version":"go1.25.0"}`, "# TYPE inspectd_uptime_info untyped\ninspectd_uptime_info{go_version=\"go1.25.0\"} 1\n"},
+		{Prometheus, `[1,2]`, "# TYPE inspectd_uptime untyped\ninspectd_uptime{index=\"0\"} 1\ninspectd_uptime{index=\"1\"} 2\n"},
+		{Prometheus, `{"a":null,"b":"x"}`, "# TYPE inspectd_uptime_info untyped\ninspectd_uptime_info{b=\"x\"} 1\n"},
+		{Prometheus, `{}`, ""},
+		{Table, `42`, "FIELD  VALUE\n       42\n"},
+		{Table, `[]`, "FIELD  VALUE\n       []\n"},
+		{NDJSON, `{"a":1}`, "{\"a\":1}\n"},
+		{NDJSON, `[ {"a": 1}, 2 ]`, "{\"a\":1}\n2\n"},
+		{NDJSON, `[]`, ""},
+		{JSONPretty, `[]`, "[]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.data, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, tt.format, "uptime", []byte(tt.data)); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Write(%s, %s) = %q, want %q", tt.format, tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+// Array elements that share a label name with an enclosing array get a
+// prefixed label instead of overwriting it.
+func TestPrometheusLabelClash(t *testing.T) {
+	data := `{"groups":[{"state":"running","frames":[{"state":"inlined","line":3}]}]}`
+	var buf bytes.Buffer
+	if err := Write(&buf, Prometheus, "goroutines", []byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	want := "# TYPE inspectd_goroutines_groups_frames_line untyped\n" +
+		"inspectd_goroutines_groups_frames_line{state=\"running\",_state=\"inlined\"} 3\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Write() = %q, want %q", got, want)
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in     string
+		metric string
+		label  string
+	}{
+		{"heap_in_use_bytes", "heap_in_use_bytes", "heap_in_use_bytes"},
+		{"/gc/cycles:total", "_gc_cycles:total", "_gc_cycles_total"},
+		{"9lives", "_9lives", "_9lives"},
+		{"a9", "a9", "a9"},
+		{"heap-in use.ratio", "heap_in_use_ratio", "heap_in_use_ratio"},
+		{"größe", "gr__e", "gr__e"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := metricName(tt.in); got != tt.metric {
+			t.Errorf("metricName(%q) = %q, want %q", tt.in, got, tt.metric)
+		}
+		if got := labelName(tt.in); got != tt.label {
+			t.Errorf("labelName(%q) = %q, want %q", tt.in, got, tt.label)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got, want := escapeLabel("C:\\dir \"x\"\nnext"), `C:\\dir \"x\"\nnext`; got != want {
+		t.Errorf("escapeLabel() = %s, want %s", got, want)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+		want   string
+	}{
+		{"yaml", `{}`, "unknown format: yaml"},
+		{JSONPretty, `{"a":`, "failed to indent output"},
+		{Logfmt, `{"a":`, "failed to decode output"},
+		{Prometheus, `{"a" 1}`, "failed to decode output"},
+		{Table, `[1,`, "failed to decode output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			err := Write(&bytes.Buffer{}, tt.format, "memory", []byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Write() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, name := range Names() {
+		if !Valid(name) {
+			t.Errorf("Valid(%s) = false", name)
+		}
+	}
+	for _, name := range []string{"", "JSON", "yaml", "csv"} {
+		if Valid(name) {
+			t.Errorf("Valid(%q) = true", name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package format
+
+import (
+	"bufio"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// writePrometheus writes the numeric and boolean fields of the output as
+// untyped samples in the Prometheus text exposition format, named
+// inspectd_<command>_<path>. String fields of array elements become labels of
+// that element's samples; other string fields become labels of a single
+// inspectd_<command>_info sample with the value 1.
+func writePrometheus(w *bufio.Writer, command string, data []byte) error {
+	value, err := decode(data)
+	if err != nil {
+		return err
+	}
+
+	p := &promWriter{prefix: "inspectd_" + metricName(command), samples: make(map[string][]string)}
+	p.walk(value, nil, nil, false)
+
+	if len(p.info) > 0 {
+		name := p.prefix + "_info"
+		p.add(name, p.info, "1")
+	}
+	for _, name := range p.names {
+		w.WriteString("# TYPE " + name + " untyped\n")
+		for _, sample := range p.samples[name] {
+			w.WriteString(sample)
+			w.WriteByte('\n')
+		}
+	}
+	return nil
+}
+
+type label struct {
+	name  string
+	value string
+}
+
+type promWriter struct {
+	prefix  string
+	names   []string
+	samples map[string][]string
+	info    []label
+}
+
+func (p *promWriter) walk(value any, path []string, labels []label, inArray bool) {
+	switch v := value.(type) {
+	case object:
+		for _, f := range v {
+			p.walk(f.value, append(path, f.key), labels, inArray)
+		}
+	case []any:
+		key := "index"
+		if len(path) > 0 {
+			key = labelName(path[len(path)-1]) + "_index"
+		}
+		for i, elem := range v {
+			elemLabels := append([]label(nil), labels...)
+			obj, ok := elem.(object)
+			if !ok || !hasString(obj) {
+				elemLabels = addLabel(elemLabels, key, strconv.Itoa(i))
+			} else {
+				for _, f := range obj {
+					if s, ok := f.value.(string); ok {
+						elemLabels = addLabel(elemLabels, labelName(f.key), s)
+					}
+				}
+			}
+			p.walk(elem, path, elemLabels, true)
+		}
+	case json.Number:
+		p.add(p.metric(path), labels, v.String())
+	case bool:
+		sample := "0"
+		if v {
+			sample = "1"
+		}
+		p.add(p.metric(path), labels, sample)
+	case string:
+		if !inArray {
+			p.info = addLabel(p.info, labelName(strings.Join(path, "_")), v)
+		}
+	}
+}
+
+func (p *promWriter) metric(path []string) string {
+	if len(path) == 0 {
+		return p.prefix
+	}
+	return p.prefix + "_" + metricName(strings.Join(path, "_"))
+}
+
+func (p *promWriter) add(name string, labels []label, value string) {
+	if _, ok := p.samples[name]; !ok {
+		p.names = append(p.names, name)
+	}
+
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(l.name)
+			b.WriteString(`="`)
+			b.WriteString(escapeLabel(l.value))
+			b.WriteByte('"')
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(value)
+	p.samples[name] = append(p.samples[name], b.String())
+}
+
+func hasString(obj object) bool {
+	for _, f := range obj {
+		if _, ok := f.value.(string); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// addLabel appends a label, prefixing its name with underscores until it does
+// not clash with an outer array's label.
+func addLabel(labels []label, name, value string) []label {
+	for taken := true; taken; {
+		taken = false
+		for _, l := range labels {
+			if l.name == name {
+				name = "_" + name
+				taken = true
+				break
+			}
+		}
+	}
+	return append(labels, label{name: name, value: value})
+}
+
+// metricName replaces characters not allowed in metric names with underscores.
+func metricName(s string) string {
+	return sanitize(s, true)
+}
+
+func labelName(s string) string {
+	return sanitize(s, false)
+}
+
+func sanitize(s string, allowColon bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		case r == ':' && allowColon:
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
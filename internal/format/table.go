@@ -0,0 +1,131 @@
+package format
+
+import (
+	"bufio"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+// writeTable writes an aligned table for reading in a terminal. A top-level
+// array of objects gets one row per element and one column per field, with
+// nested values as compact JSON. Anything else is listed as dotted-path
+// FIELD/VALUE rows, with arrays of scalars kept on one row.
+func writeTable(w *bufio.Writer, data []byte) error {
+	value, err := decode(data)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if rows, ok := objectRows(value); ok {
+		writeRows(tw, rows)
+	} else {
+		tw.Write([]byte("FIELD\tVALUE\n"))
+		walkTable(value, nil, func(path []string, cell string) {
+			tw.Write([]byte(tableText(strings.Join(path, ".")) + "\t" + cell + "\n"))
+		})
+	}
+	return tw.Flush()
+}
+
+func objectRows(value any) ([]object, bool) {
+	elems, ok := value.([]any)
+	if !ok || len(elems) == 0 {
+		return nil, false
+	}
+	rows := make([]object, len(elems))
+	for i, elem := range elems {
+		if rows[i], ok = elem.(object); !ok {
+			return nil, false
+		}
+	}
+	return rows, true
+}
+
+func writeRows(tw *tabwriter.Writer, rows []object) {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, f := range row {
+			if !seen[f.key] {
+				seen[f.key] = true
+				columns = append(columns, f.key)
+			}
+		}
+	}
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = tableText(strings.ToUpper(column))
+	}
+	tw.Write([]byte(strings.Join(header, "\t") + "\n"))
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			for _, f := range row {
+				if f.key == column {
+					cells[i] = cell(f.value)
+					break
+				}
+			}
+		}
+		tw.Write([]byte(strings.Join(cells, "\t") + "\n"))
+	}
+}
+
+// walkTable is walk with arrays of scalars reported as a single cell.
+func walkTable(value any, path []string, fn func(path []string, cell string)) {
+	switch v := value.(type) {
+	case object:
+		for _, f := range v {
+			walkTable(f.value, append(path, f.key), fn)
+		}
+	case []any:
+		if scalars(v) {
+			fn(path, cell(v))
+			return
+		}
+		for i, elem := range v {
+			walkTable(elem, append(path, strconv.Itoa(i)), fn)
+		}
+	default:
+		fn(path, cell(v))
+	}
+}
+
+func scalars(values []any) bool {
+	for _, v := range values {
+		switch v.(type) {
+		case object, []any:
+			return false
+		}
+	}
+	return true
+}
+
+func cell(value any) string {
+	switch v := value.(type) {
+	case object, []any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	case nil:
+		return "null"
+	case string:
+		return tableText(v)
+	default:
+		return scalarString(v)
+	}
+}
+
+// tableText quotes text holding tabs, newlines or other control characters,
+// which would otherwise break the row and column layout.
+func tableText(s string) string {
+	if strings.IndexFunc(s, unicode.IsControl) >= 0 {
+		return strconv.Quote(s)
+	}
+	return s
+}
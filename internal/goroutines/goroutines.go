@@ -1,19 +1,79 @@
 package goroutines
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"hash/fnv"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type GoroutineInfo struct {
-	TotalCount int `json:"total_count"`
+	TotalCount int            `json:"total_count"`
+	States     map[string]int `json:"states,omitempty"`
+	Groups     []Group        `json:"groups,omitempty"`
+	Goroutines []Goroutine    `json:"goroutines,omitempty"`
+}
+
+// Group is a set of goroutines in the same state with an identical stack.
+type Group struct {
+	Signature      string  `json:"signature"`
+	State          string  `json:"state"`
+	Count          int     `json:"count"`
+	MaxWaitMinutes int     `json:"max_wait_minutes"`
+	CreatedBy      *Frame  `json:"created_by,omitempty"`
+	Frames         []Frame `json:"frames"`
+}
+
+type Goroutine struct {
+	ID             int64   `json:"id"`
+	State          string  `json:"state"`
+	WaitMinutes    int     `json:"wait_minutes"`
+	LockedToThread bool    `json:"locked_to_thread"`
+	CreatedBy      *Frame  `json:"created_by,omitempty"`
+	Frames         []Frame `json:"frames"`
+}
+
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
 }
 
 func Collect() (*GoroutineInfo, error) {
 	info := &GoroutineInfo{
 		TotalCount: runtime.NumGoroutine(),
 	}
-	
+
+	return info, nil
+}
+
+// CollectStacks parses a full goroutine dump. runtime.Stack stops the world
+// while the dump is taken, so the pause grows with the number of goroutines.
+func CollectStacks() (*GoroutineInfo, error) {
+	goroutines := parseStacks(dumpStacks())
+
+	info := &GoroutineInfo{
+		TotalCount: len(goroutines),
+		States:     countStates(goroutines),
+		Groups:     groupStacks(goroutines),
+		Goroutines: goroutines,
+	}
+
+	return info, nil
+}
+
+// CollectGroups is CollectStacks without the per-goroutine records, keeping output
+// proportional to the number of distinct stacks rather than the number of goroutines.
+func CollectGroups() (*GoroutineInfo, error) {
+	info, err := CollectStacks()
+	if err != nil {
+		return nil, err
+	}
+	info.Goroutines = nil
 	return info, nil
 }
 
@@ -25,3 +85,222 @@ func CollectJSON() ([]byte, error) {
 	return json.Marshal(info)
 }
 
+func CollectStacksJSON() ([]byte, error) {
+	info, err := CollectStacks()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
+
+func CollectGroupsJSON() ([]byte, error) {
+	info, err := CollectGroups()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
+
+// dumpStacks returns runtime.Stack output for all goroutines, growing the buffer until it fits.
+func dumpStacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// parseStacks parses the text format produced by runtime.Stack:
+//
+//	goroutine 7 [chan receive, 5 minutes]:
+//	main.worker(0xc000010000)
+//		/app/worker.go:88 +0x3d
+//	created by main.main in goroutine 1
+//		/app/main.go:12 +0x25
+func parseStacks(dump []byte) []Goroutine {
+	goroutines := make([]Goroutine, 0)
+
+	var current *Goroutine
+	var function string
+	createdBy := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(dump))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "goroutine "):
+			g, ok := parseHeader(line)
+			if !ok {
+				current = nil
+				continue
+			}
+			goroutines = append(goroutines, g)
+			current = &goroutines[len(goroutines)-1]
+			function = ""
+			createdBy = false
+
+		case current == nil || line == "":
+			continue
+
+		case strings.HasPrefix(line, "\t"):
+			if function == "" {
+				continue
+			}
+			file, lineNo := parseLocation(line)
+			frame := Frame{Function: function, File: file, Line: lineNo}
+			if createdBy {
+				current.CreatedBy = &frame
+			} else {
+				current.Frames = append(current.Frames, frame)
+			}
+			function = ""
+
+		case strings.HasPrefix(line, "created by "):
+			function = strings.TrimPrefix(line, "created by ")
+			if i := strings.Index(function, " in goroutine "); i >= 0 {
+				function = function[:i]
+			}
+			createdBy = true
+
+		case strings.HasPrefix(line, "..."):
+			// "...additional frames elided..." marks a truncated stack.
+			continue
+
+		default:
+			function = trimArgs(line)
+		}
+	}
+
+	for i := range goroutines {
+		if goroutines[i].Frames == nil {
+			goroutines[i].Frames = []Frame{}
+		}
+	}
+
+	return goroutines
+}
+
+// parseHeader parses a "goroutine <id> [<state>, <n> minutes, locked to thread]:" line.
+func parseHeader(line string) (Goroutine, bool) {
+	var g Goroutine
+
+	fields := strings.SplitN(strings.TrimPrefix(line, "goroutine "), " ", 2)
+	if len(fields) != 2 {
+		return g, false
+	}
+	id, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return g, false
+	}
+	g.ID = id
+
+	open := strings.Index(line, "[")
+	close := strings.LastIndex(line, "]")
+	if open < 0 || close < open {
+		return g, false
+	}
+
+	for i, part := range strings.Split(line[open+1:close], ", ") {
+		switch {
+		case i == 0:
+			g.State = part
+		case part == "locked to thread":
+			g.LockedToThread = true
+		case strings.HasSuffix(part, " minutes"):
+			g.WaitMinutes, _ = strconv.Atoi(strings.TrimSuffix(part, " minutes"))
+		}
+	}
+
+	return g, true
+}
+
+// parseLocation parses a "\t/path/file.go:123 +0x1d" line.
+func parseLocation(line string) (string, int) {
+	location := strings.TrimSpace(line)
+	if i := strings.LastIndex(location, " +0x"); i >= 0 {
+		location = location[:i]
+	}
+
+	i := strings.LastIndex(location, ":")
+	if i < 0 {
+		return location, 0
+	}
+	lineNo, err := strconv.Atoi(location[i+1:])
+	if err != nil {
+		return location, 0
+	}
+	return location[:i], lineNo
+}
+
+// trimArgs strips the argument list from a "pkg.(*T).Method(0x1, 0x2)" line.
+func trimArgs(line string) string {
+	if !strings.HasSuffix(line, ")") {
+		return line
+	}
+	if i := strings.LastIndex(line, "("); i > 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func countStates(goroutines []Goroutine) map[string]int {
+	states := make(map[string]int)
+	for _, g := range goroutines {
+		states[g.State]++
+	}
+	return states
+}
+
+// groupStacks groups goroutines by state and stack, largest group first.
+func groupStacks(goroutines []Goroutine) []Group {
+	index := make(map[string]int)
+	groups := make([]Group, 0)
+
+	for _, g := range goroutines {
+		signature := stackSignature(g)
+		i, ok := index[signature]
+		if !ok {
+			i = len(groups)
+			index[signature] = i
+			groups = append(groups, Group{
+				Signature: signature,
+				State:     g.State,
+				CreatedBy: g.CreatedBy,
+				Frames:    g.Frames,
+			})
+		}
+
+		groups[i].Count++
+		if g.WaitMinutes > groups[i].MaxWaitMinutes {
+			groups[i].MaxWaitMinutes = g.WaitMinutes
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Signature < groups[j].Signature
+	})
+
+	return groups
+}
+
+// stackSignature hashes the state, frames and creator of a goroutine. It is stable
+// across processes running the same binary, so groups can be compared between snapshots.
+func stackSignature(g Goroutine) string {
+	h := fnv.New64a()
+	h.Write([]byte(g.State))
+	for _, f := range g.Frames {
+		h.Write([]byte("\n" + f.Function + " " + f.File + ":" + strconv.Itoa(f.Line)))
+	}
+	if g.CreatedBy != nil {
+		h.Write([]byte("\ncreated by " + g.CreatedBy.Function + " " + g.CreatedBy.File + ":" + strconv.Itoa(g.CreatedBy.Line)))
+	}
+	return strconv.FormatUint(h.Sum64(), 16)
+}
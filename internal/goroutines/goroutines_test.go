@@ -0,0 +1,276 @@
+package goroutines
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   Goroutine
+		wantOK bool
+	}{
+		{"goroutine 1 [running]:", Goroutine{ID: 1, State: "running"}, true},
+		{"goroutine 7 [chan receive, 12 minutes]:", Goroutine{ID: 7, State: "chan receive", WaitMinutes: 12}, true},
+		{"goroutine 3 [syscall, locked to thread]:", Goroutine{ID: 3, State: "syscall", LockedToThread: true}, true},
+		{"goroutine 42 [select, 3 minutes, locked to thread]:", Goroutine{ID: 42, State: "select", WaitMinutes: 3, LockedToThread: true}, true},
+		{"goroutine 5 [sync.Mutex.Lock]:", Goroutine{ID: 5, State: "sync.Mutex.Lock"}, true},
+		{"goroutine 9 gp=0xc000 m=nil [GC worker (idle)]:", Goroutine{ID: 9, State: "GC worker (idle)"}, true},
+
+		{"goroutine", Goroutine{}, false},
+		{"goroutine 1", Goroutine{}, false},
+		{"goroutine x [running]:", Goroutine{}, false},
+		{"goroutine 1 running:", Goroutine{ID: 1}, false},
+		{"goroutine 1 ]running[:", Goroutine{ID: 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := parseHeader(tt.line)
+			if ok != tt.wantOK {
+				t.Fatalf("parseHeader() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantFile string
+		wantLine int
+	}{
+		{"\t/src/app/main.go:42 +0x1d", "/src/app/main.go", 42},
+		{"\t/src/app/main.go:42", "/src/app/main.go", 42},
+		{"\tC:/src/app/main.go:7 +0x25", "C:/src/app/main.go", 7},
+		{"\t/usr/local/go/src/runtime/proc.go:435 +0xce fp=0xc000 sp=0xc000 pc=0x4", "/usr/local/go/src/runtime/proc.go", 435},
+		{"\t/src/app/main.go", "/src/app/main.go", 0},
+		{"\t/src/app/main.go:abc", "/src/app/main.go:abc", 0},
+		{"\t?:0", "?", 0},
+	}
+	for _, tt := range tests {
+		file, line := parseLocation(tt.line)
+		if file != tt.wantFile || line != tt.wantLine {
+			t.Errorf("parseLocation(%q) = %q, %d, want %q, %d", tt.line, file, line, tt.wantFile, tt.wantLine)
+		}
+	}
+}
+
+func TestTrimArgs(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{"main.main()", "main.main"},
+		{"main.worker(0xc000012345, 0x3)", "main.worker"},
+		{"net/http.(*conn).serve(0xc0001a4000, {0x7f8, 0xc0000b4000})", "net/http.(*conn).serve"},
+		{"example.com/pkg.(*T).M(...)", "example.com/pkg.(*T).M"},
+		{"example.com/pkg.T.M(...)", "example.com/pkg.T.M"},
+		{"main.main.func1()", "main.main.func1"},
+		{"example.com/pkg.Map[...](0x1)", "example.com/pkg.Map[...]"},
+		{"runtime.gopark", "runtime.gopark"},
+		{"(unknown)", "(unknown)"},
+	}
+	for _, tt := range tests {
+		if got := trimArgs(tt.line); got != tt.want {
+			t.Errorf("trimArgs(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseStacks(t *testing.T) {
+	dump := `goroutine 1 [running]:
+main.main()
+	/src/app/main.go:10 +0x1d
+
+goroutine 18 [chan receive, 5 minutes, locked to thread]:
+example.com/pkg.(*Server).wait(...)
+	/src/pkg/server.go:88
+example.com/pkg.(*Server).Run(0xc000010000)
+	/src/pkg/server.go:42 +0x45
+created by main.main in goroutine 1
+	/src/app/main.go:12 +0x65
+
+goroutine 19 [select]:
+main.deep(0x3e8)
+	/src/app/deep.go:5 +0x10
+...additional frames elided...
+created by main.start
+	/src/app/main.go:20 +0x30
+
+goroutine bogus [running]:
+main.ignored()
+	/src/app/ignored.go:1 +0x1
+
+goroutine 20 [runnable]:
+`
+
+	want := []Goroutine{
+		{
+			ID:    1,
+			State: "running",
+			Frames: []Frame{
+				{Function: "main.main", File: "/src/app/main.go", Line: 10},
+			},
+		},
+		{
+			ID:             18,
+			State:          "chan receive",
+			WaitMinutes:    5,
+			LockedToThread: true,
+			CreatedBy:      &Frame{Function: "main.main", File: "/src/app/main.go", Line: 12},
+			Frames: []Frame{
+				{Function: "example.com/pkg.(*Server).wait", File: "/src/pkg/server.go", Line: 88},
+				{Function: "example.com/pkg.(*Server).Run", File: "/src/pkg/server.go", Line: 42},
+			},
+		},
+		{
+			ID:        19,
+			State:     "select",
+			CreatedBy: &Frame{Function: "main.start", File: "/src/app/main.go", Line: 20},
+			Frames: []Frame{
+				{Function: "main.deep", File: "/src/app/deep.go", Line: 5},
+			},
+		},
+		{
+			ID:     20,
+			State:  "runnable",
+			Frames: []Frame{},
+		},
+	}
+
+	got := parseStacks([]byte(dump))
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStacks() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseStacksEmpty(t *testing.T) {
+	got := parseStacks(nil)
+	if got == nil || len(got) != 0 {
+		t.Errorf("parseStacks(nil) = %#v, want an empty slice", got)
+	}
+}
+
+func TestParseStacksLive(t *testing.T) {
+	goroutines := parseStacks(dumpStacks())
+	if len(goroutines) == 0 {
+		t.Fatal("no goroutines parsed from the current process")
+	}
+	for _, g := range goroutines {
+		if g.ID <= 0 || g.State == "" {
+			t.Errorf("goroutine %+v has no ID or state", g)
+		}
+		for _, f := range g.Frames {
+			if f.Function == "" || f.File == "" || f.Line <= 0 {
+				t.Errorf("goroutine %d has incomplete frame %+v", g.ID, f)
+			}
+		}
+	}
+}
+
+func TestCountStates(t *testing.T) {
+	goroutines := []Goroutine{{State: "running"}, {State: "chan receive"}, {State: "chan receive"}, {State: "select"}}
+	want := map[string]int{"running": 1, "chan receive": 2, "select": 1}
+	if got := countStates(goroutines); !reflect.DeepEqual(got, want) {
+		t.Errorf("countStates() = %v, want %v", got, want)
+	}
+	if got := countStates(nil); got == nil || len(got) != 0 {
+		t.Errorf("countStates(nil) = %#v, want an empty map", got)
+	}
+}
+
+var (
+	serveFrames = []Frame{{Function: "main.serve", File: "/src/app/main.go", Line: 20}}
+	waitFrames  = []Frame{{Function: "main.wait", File: "/src/app/main.go", Line: 30}}
+	creator     = &Frame{Function: "main.main", File: "/src/app/main.go", Line: 10}
+)
+
+func TestStackSignature(t *testing.T) {
+	base := Goroutine{ID: 1, State: "chan receive", Frames: serveFrames, CreatedBy: creator}
+
+	// Signatures are compared across processes and snapshots, so the hash must not change.
+	if got, want := stackSignature(base), "22983576edd87e0c"; got != want {
+		t.Errorf("stackSignature() = %s, want %s", got, want)
+	}
+
+	same := []Goroutine{
+		{ID: 2, State: "chan receive", Frames: serveFrames, CreatedBy: creator},
+		{ID: 3, State: "chan receive", WaitMinutes: 9, LockedToThread: true, Frames: serveFrames, CreatedBy: creator},
+		{ID: 4, State: "chan receive", Frames: []Frame{serveFrames[0]}, CreatedBy: &Frame{Function: "main.main", File: "/src/app/main.go", Line: 10}},
+	}
+	for _, g := range same {
+		if stackSignature(g) != stackSignature(base) {
+			t.Errorf("goroutine %+v has a different signature from %+v", g, base)
+		}
+	}
+
+	different := map[string]Goroutine{
+		"state":       {State: "select", Frames: serveFrames, CreatedBy: creator},
+		"function":    {State: "chan receive", Frames: waitFrames, CreatedBy: creator},
+		"line":        {State: "chan receive", Frames: []Frame{{Function: "main.serve", File: "/src/app/main.go", Line: 21}}, CreatedBy: creator},
+		"extra frame": {State: "chan receive", Frames: append([]Frame{}, serveFrames[0], waitFrames[0]), CreatedBy: creator},
+		"no creator":  {State: "chan receive", Frames: serveFrames},
+		"creator":     {State: "chan receive", Frames: serveFrames, CreatedBy: &Frame{Function: "main.start", File: "/src/app/main.go", Line: 10}},
+	}
+	for name, g := range different {
+		if stackSignature(g) == stackSignature(base) {
+			t.Errorf("changing the %s does not change the signature", name)
+		}
+	}
+}
+
+func TestGroupStacks(t *testing.T) {
+	goroutines := []Goroutine{
+		{ID: 1, State: "running", Frames: serveFrames},
+		{ID: 2, State: "chan receive", WaitMinutes: 3, Frames: waitFrames, CreatedBy: creator},
+		{ID: 3, State: "chan receive", WaitMinutes: 7, Frames: waitFrames, CreatedBy: creator},
+		{ID: 4, State: "chan receive", Frames: waitFrames, CreatedBy: creator},
+		{ID: 5, State: "select", Frames: serveFrames},
+		{ID: 6, State: "select", WaitMinutes: 1, Frames: serveFrames},
+		{ID: 7, State: "chan receive", Frames: serveFrames},
+	}
+
+	groups := groupStacks(goroutines)
+
+	type summary struct {
+		state          string
+		count, maxWait int
+		creator        bool
+	}
+	var got []summary
+	for _, g := range groups {
+		got = append(got, summary{g.State, g.Count, g.MaxWaitMinutes, g.CreatedBy != nil})
+	}
+	want := []summary{{"chan receive", 3, 7, true}, {"select", 2, 1, false}}
+	if len(got) != 4 || !reflect.DeepEqual(got[:2], want) {
+		t.Fatalf("groupStacks() = %+v, want %+v followed by the two single goroutines", got, want)
+	}
+
+	// Groups of equal size are ordered by signature so output is stable.
+	if groups[2].Signature >= groups[3].Signature {
+		t.Errorf("groups of equal size not ordered by signature: %s, %s", groups[2].Signature, groups[3].Signature)
+	}
+	for i, g := range groups {
+		if g.Signature != stackSignature(Goroutine{State: g.State, Frames: g.Frames, CreatedBy: g.CreatedBy}) {
+			t.Errorf("group %d signature %s does not match its state and stack", i, g.Signature)
+		}
+	}
+
+	// Input order does not affect the result.
+	reversed := make([]Goroutine, len(goroutines))
+	for i, g := range goroutines {
+		reversed[len(goroutines)-1-i] = g
+	}
+	if again := groupStacks(reversed); !reflect.DeepEqual(again, groups) {
+		t.Errorf("groupStacks() depends on input order:\n%+v\n%+v", again, groups)
+	}
+}
+
+func TestGroupStacksEmpty(t *testing.T) {
+	if got := groupStacks(nil); got == nil || len(got) != 0 {
+		t.Errorf("groupStacks(nil) = %#v, want an empty slice", got)
+	}
+}
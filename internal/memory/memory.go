@@ -2,38 +2,156 @@ package memory
 
 import (
 	"encoding/json"
-	"runtime"
+	"math"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/rtmetrics"
 )
 
 type MemoryInfo struct {
-	HeapInUse      uint64  `json:"heap_in_use_bytes"`
-	HeapAllocated  uint64  `json:"heap_allocated_bytes"`
-	HeapObjects    uint64  `json:"heap_objects"`
-	TotalAlloc     uint64  `json:"total_alloc_bytes"`
-	GCCycles       uint32  `json:"gc_cycles"`
-	LastGCPause    float64 `json:"last_gc_pause_seconds"`
-	GCCPUFraction  float64 `json:"gc_cpu_fraction"`
+	HeapInUse         uint64        `json:"heap_in_use_bytes"`
+	HeapAllocated     uint64        `json:"heap_allocated_bytes"`
+	HeapObjects       uint64        `json:"heap_objects"`
+	TotalAlloc        uint64        `json:"total_alloc_bytes"`
+	GCCycles          uint32        `json:"gc_cycles"`
+	LastGCPause       float64       `json:"last_gc_pause_seconds"`
+	GCCPUFraction     float64       `json:"gc_cpu_fraction"`
+	HeapLive          uint64        `json:"heap_live_bytes"`
+	HeapGoal          uint64        `json:"heap_goal_bytes"`
+	TotalFree         uint64        `json:"total_free_bytes"`
+	TotalAllocObjects uint64        `json:"total_alloc_objects"`
+	TotalFreeObjects  uint64        `json:"total_free_objects"`
+	TinyAllocObjects  uint64        `json:"tiny_alloc_objects"`
+	GCCyclesForced    uint64        `json:"gc_cycles_forced"`
+	GCCyclesAutomatic uint64        `json:"gc_cycles_automatic"`
+	GCCPUSeconds      float64       `json:"gc_cpu_seconds"`
+	GCScanHeap        uint64        `json:"gc_scan_heap_bytes"`
+	GCScanStack       uint64        `json:"gc_scan_stack_bytes"`
+	GCScanGlobals     uint64        `json:"gc_scan_globals_bytes"`
+	FinalizersQueued  uint64        `json:"finalizers_queued"`
+	GCPauses          *GCPauseStats `json:"gc_pauses"`
+	Breakdown         *Breakdown    `json:"breakdown"`
+}
+
+// Breakdown accounts for all memory mapped by the Go runtime, from /memory/classes/*.
+// The classes sum to Sys; Retained is the part not yet returned to the OS.
+type Breakdown struct {
+	Sys              uint64 `json:"sys_bytes"`
+	Retained         uint64 `json:"retained_bytes"`
+	HeapObjects      uint64 `json:"heap_objects_bytes"`
+	HeapUnused       uint64 `json:"heap_unused_bytes"`
+	HeapFree         uint64 `json:"heap_free_bytes"`
+	HeapReleased     uint64 `json:"heap_released_bytes"`
+	HeapIdle         uint64 `json:"heap_idle_bytes"`
+	StackInUse       uint64 `json:"stack_in_use_bytes"`
+	OSStacks         uint64 `json:"os_stacks_bytes"`
+	MSpanInUse       uint64 `json:"mspan_in_use_bytes"`
+	MSpanFree        uint64 `json:"mspan_free_bytes"`
+	MCacheInUse      uint64 `json:"mcache_in_use_bytes"`
+	MCacheFree       uint64 `json:"mcache_free_bytes"`
+	GCMetadata       uint64 `json:"gc_metadata_bytes"`
+	ProfilingBuckets uint64 `json:"profiling_buckets_bytes"`
+	Other            uint64 `json:"other_bytes"`
+}
+
+// GCPauseStats summarizes the stop-the-world pauses of recent GC cycles.
+// Percentiles cover the runtime's pause history (up to the last 256 cycles).
+type GCPauseStats struct {
+	Count  int       `json:"count"`
+	P50    float64   `json:"p50_seconds"`
+	P90    float64   `json:"p90_seconds"`
+	P99    float64   `json:"p99_seconds"`
+	Max    float64   `json:"max_seconds"`
+	Total  float64   `json:"total_seconds"`
+	Recent []GCPause `json:"recent"`
+}
+
+type GCPause struct {
+	EndedAt string  `json:"ended_at"`
+	Pause   float64 `json:"pause_seconds"`
+}
+
+// maxRecentPauses bounds the pause history included in each snapshot.
+const maxRecentPauses = 32
+
+var memoryMetrics = []string{
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/heap/unused:bytes",
+	"/gc/heap/objects:objects",
+	"/gc/heap/allocs:bytes",
+	"/gc/heap/allocs:objects",
+	"/gc/heap/frees:bytes",
+	"/gc/heap/frees:objects",
+	"/gc/heap/tiny/allocs:objects",
+	"/gc/heap/live:bytes",
+	"/gc/heap/goal:bytes",
+	"/gc/cycles/total:gc-cycles",
+	"/gc/cycles/forced:gc-cycles",
+	"/gc/cycles/automatic:gc-cycles",
+	"/gc/scan/heap:bytes",
+	"/gc/scan/stack:bytes",
+	"/gc/scan/globals:bytes",
+	"/gc/finalizers/queued:finalizers",
+	"/cpu/classes/gc/total:cpu-seconds",
+	"/cpu/classes/total:cpu-seconds",
+	"/memory/classes/total:bytes",
+	"/memory/classes/heap/free:bytes",
+	"/memory/classes/heap/released:bytes",
+	"/memory/classes/heap/stacks:bytes",
+	"/memory/classes/os-stacks:bytes",
+	"/memory/classes/metadata/mspan/inuse:bytes",
+	"/memory/classes/metadata/mspan/free:bytes",
+	"/memory/classes/metadata/mcache/inuse:bytes",
+	"/memory/classes/metadata/mcache/free:bytes",
+	"/memory/classes/metadata/other:bytes",
+	"/memory/classes/profiling/buckets:bytes",
+	"/memory/classes/other:bytes",
 }
 
 func Collect() (*MemoryInfo, error) {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
-	
+	s := rtmetrics.Read(memoryMetrics...)
+
+	// ReadGCStats takes the heap lock but, unlike ReadMemStats, does not stop the world.
+	var gcStats debug.GCStats
+	debug.ReadGCStats(&gcStats)
+
 	var lastGCPause float64
-	if m.NumGC > 0 {
-		lastGCPause = float64(m.PauseNs[(m.NumGC+255)%256]) / 1e9
+	if len(gcStats.Pause) > 0 {
+		lastGCPause = gcStats.Pause[0].Seconds()
 	}
-	
+
+	gcPauses := pauseStats(&gcStats)
+
+	gcCPUSeconds := s.Float64("/cpu/classes/gc/total:cpu-seconds")
+	breakdown := memoryBreakdown(s)
+
 	info := &MemoryInfo{
-		HeapInUse:     m.HeapInuse,
-		HeapAllocated: m.Alloc,
-		HeapObjects:   m.HeapObjects,
-		TotalAlloc:    m.TotalAlloc,
-		GCCycles:      m.NumGC,
-		LastGCPause:   lastGCPause,
-		GCCPUFraction: m.GCCPUFraction,
-	}
-	
+		HeapInUse:         breakdown.heapInUse(),
+		HeapAllocated:     breakdown.HeapObjects,
+		HeapObjects:       s.Uint64("/gc/heap/objects:objects"),
+		TotalAlloc:        s.Uint64("/gc/heap/allocs:bytes"),
+		GCCycles:          uint32(s.Uint64("/gc/cycles/total:gc-cycles")),
+		LastGCPause:       lastGCPause,
+		GCCPUFraction:     fraction(gcCPUSeconds, s.Float64("/cpu/classes/total:cpu-seconds")),
+		HeapLive:          s.Uint64("/gc/heap/live:bytes"),
+		HeapGoal:          s.Uint64("/gc/heap/goal:bytes"),
+		TotalFree:         s.Uint64("/gc/heap/frees:bytes"),
+		TotalAllocObjects: s.Uint64("/gc/heap/allocs:objects"),
+		TotalFreeObjects:  s.Uint64("/gc/heap/frees:objects"),
+		TinyAllocObjects:  s.Uint64("/gc/heap/tiny/allocs:objects"),
+		GCCyclesForced:    s.Uint64("/gc/cycles/forced:gc-cycles"),
+		GCCyclesAutomatic: s.Uint64("/gc/cycles/automatic:gc-cycles"),
+		GCCPUSeconds:      gcCPUSeconds,
+		GCScanHeap:        s.Uint64("/gc/scan/heap:bytes"),
+		GCScanStack:       s.Uint64("/gc/scan/stack:bytes"),
+		GCScanGlobals:     s.Uint64("/gc/scan/globals:bytes"),
+		FinalizersQueued:  s.Uint64("/gc/finalizers/queued:finalizers"),
+		GCPauses:          gcPauses,
+		Breakdown:         breakdown,
+	}
+
 	return info, nil
 }
 
@@ -45,3 +163,86 @@ func CollectJSON() ([]byte, error) {
 	return json.Marshal(info)
 }
 
+func memoryBreakdown(s rtmetrics.Samples) *Breakdown {
+	b := &Breakdown{
+		Sys:              s.Uint64("/memory/classes/total:bytes"),
+		HeapObjects:      s.Uint64("/memory/classes/heap/objects:bytes"),
+		HeapUnused:       s.Uint64("/memory/classes/heap/unused:bytes"),
+		HeapFree:         s.Uint64("/memory/classes/heap/free:bytes"),
+		HeapReleased:     s.Uint64("/memory/classes/heap/released:bytes"),
+		StackInUse:       s.Uint64("/memory/classes/heap/stacks:bytes"),
+		OSStacks:         s.Uint64("/memory/classes/os-stacks:bytes"),
+		MSpanInUse:       s.Uint64("/memory/classes/metadata/mspan/inuse:bytes"),
+		MSpanFree:        s.Uint64("/memory/classes/metadata/mspan/free:bytes"),
+		MCacheInUse:      s.Uint64("/memory/classes/metadata/mcache/inuse:bytes"),
+		MCacheFree:       s.Uint64("/memory/classes/metadata/mcache/free:bytes"),
+		GCMetadata:       s.Uint64("/memory/classes/metadata/other:bytes"),
+		ProfilingBuckets: s.Uint64("/memory/classes/profiling/buckets:bytes"),
+		Other:            s.Uint64("/memory/classes/other:bytes"),
+	}
+	b.derive()
+	return b
+}
+
+// derive fills in the totals computed from the memory classes: idle heap is
+// free plus released spans, and retained memory is everything mapped but not
+// yet returned to the OS.
+func (b *Breakdown) derive() {
+	b.HeapIdle = b.HeapFree + b.HeapReleased
+	b.Retained = b.Sys - min(b.HeapReleased, b.Sys)
+}
+
+// heapInUse is the memory in spans holding objects, including the unused
+// space at the end of those spans, as in MemStats.HeapInuse.
+func (b *Breakdown) heapInUse() uint64 {
+	return b.HeapObjects + b.HeapUnused
+}
+
+// fraction returns part/total, or 0 before any total has accumulated.
+func fraction(part, total float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return part / total
+}
+
+func pauseStats(gcStats *debug.GCStats) *GCPauseStats {
+	stats := &GCPauseStats{
+		Count:  len(gcStats.Pause),
+		Total:  gcStats.PauseTotal.Seconds(),
+		Recent: make([]GCPause, 0, min(len(gcStats.Pause), maxRecentPauses)),
+	}
+
+	// Pause and PauseEnd are ordered most recent first.
+	for i := 0; i < len(gcStats.Pause) && i < maxRecentPauses; i++ {
+		pause := GCPause{Pause: gcStats.Pause[i].Seconds()}
+		if i < len(gcStats.PauseEnd) {
+			pause.EndedAt = gcStats.PauseEnd[i].UTC().Format(time.RFC3339Nano)
+		}
+		stats.Recent = append(stats.Recent, pause)
+	}
+
+	if len(gcStats.Pause) == 0 {
+		return stats
+	}
+
+	sorted := make([]time.Duration, len(gcStats.Pause))
+	copy(sorted, gcStats.Pause)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	stats.P50 = percentile(sorted, 0.50)
+	stats.P90 = percentile(sorted, 0.90)
+	stats.P99 = percentile(sorted, 0.99)
+	stats.Max = sorted[len(sorted)-1].Seconds()
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of an ascending slice, in seconds.
+func percentile(sorted []time.Duration, q float64) float64 {
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank].Seconds()
+}
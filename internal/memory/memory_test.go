@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"math/rand"
+	"runtime/debug"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBreakdownDerive(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           Breakdown
+		wantIdle     uint64
+		wantRetained uint64
+		wantInUse    uint64
+	}{
+		{
+			name:         "typical",
+			in:           Breakdown{Sys: 100 << 20, HeapObjects: 30 << 20, HeapUnused: 2 << 20, HeapFree: 8 << 20, HeapReleased: 40 << 20},
+			wantIdle:     48 << 20,
+			wantRetained: 60 << 20,
+			wantInUse:    32 << 20,
+		},
+		{
+			name:         "nothing released",
+			in:           Breakdown{Sys: 10 << 20, HeapObjects: 4 << 20, HeapFree: 1 << 20},
+			wantIdle:     1 << 20,
+			wantRetained: 10 << 20,
+			wantInUse:    4 << 20,
+		},
+		{
+			name:         "inconsistent read",
+			in:           Breakdown{Sys: 1 << 20, HeapReleased: 2 << 20},
+			wantIdle:     2 << 20,
+			wantRetained: 0,
+		},
+		{name: "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.in
+			b.derive()
+			if b.HeapIdle != tt.wantIdle || b.Retained != tt.wantRetained {
+				t.Errorf("derive() = idle %d, retained %d, want %d, %d", b.HeapIdle, b.Retained, tt.wantIdle, tt.wantRetained)
+			}
+			if got := b.heapInUse(); got != tt.wantInUse {
+				t.Errorf("heapInUse() = %d, want %d", got, tt.wantInUse)
+			}
+		})
+	}
+}
+
+func TestFraction(t *testing.T) {
+	tests := []struct {
+		part, total, want float64
+	}{
+		{0.5, 10, 0.05},
+		{0, 10, 0},
+		{1, 0, 0},
+		{1, -1, 0},
+	}
+	for _, tt := range tests {
+		if got := fraction(tt.part, tt.total); got != tt.want {
+			t.Errorf("fraction(%g, %g) = %g, want %g", tt.part, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	ms := func(n ...int) []time.Duration {
+		out := make([]time.Duration, len(n))
+		for i, v := range n {
+			out[i] = time.Duration(v) * time.Millisecond
+		}
+		return out
+	}
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		q      float64
+		want   float64
+	}{
+		{"single", ms(7), 0.5, 0.007},
+		{"single p99", ms(7), 0.99, 0.007},
+		{"p50 of ten", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 0.50, 0.005},
+		{"p90 of ten", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 0.90, 0.009},
+		{"p99 of ten rounds up", ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), 0.99, 0.010},
+		{"p50 of odd count", ms(1, 2, 3), 0.50, 0.002},
+		{"q of zero", ms(1, 2, 3), 0, 0.001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.q); got != tt.want {
+				t.Errorf("percentile(%v, %g) = %g, want %g", tt.sorted, tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+// The runtime keeps the last 256 pauses, most recent first.
+func TestPauseStatsFullHistory(t *testing.T) {
+	const n = 256
+	end := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	stats := &debug.GCStats{PauseTotal: 10 * time.Second}
+	for _, i := range rand.New(rand.NewSource(1)).Perm(n) {
+		stats.Pause = append(stats.Pause, time.Duration(i+1)*time.Microsecond)
+	}
+	for i := range n {
+		stats.PauseEnd = append(stats.PauseEnd, end.Add(-time.Duration(i)*time.Second))
+	}
+
+	original := slices.Clone(stats.Pause)
+	got := pauseStats(stats)
+	if !slices.Equal(stats.Pause, original) {
+		t.Error("pauseStats reordered the pauses it was given")
+	}
+	if got.Count != n || got.Total != 10 {
+		t.Errorf("count %d, total %g, want %d, 10", got.Count, got.Total, n)
+	}
+	// Nearest rank: the 128th, 231st and 254th of 1..256 µs.
+	if got.P50 != 128e-6 || got.P90 != 231e-6 || got.P99 != 254e-6 || got.Max != 256e-6 {
+		t.Errorf("p50 %g, p90 %g, p99 %g, max %g, want 128µs, 231µs, 254µs, 256µs", got.P50, got.P90, got.P99, got.Max)
+	}
+	if len(got.Recent) != maxRecentPauses {
+		t.Fatalf("%d recent pauses, want %d", len(got.Recent), maxRecentPauses)
+	}
+	for i, pause := range got.Recent {
+		if pause.Pause != stats.Pause[i].Seconds() {
+			t.Errorf("Recent[%d] = %g, want %g (most recent first)", i, pause.Pause, stats.Pause[i].Seconds())
+		}
+	}
+	if got.Recent[0].EndedAt != "2025-01-01T12:00:00Z" || got.Recent[1].EndedAt != "2025-01-01T11:59:59Z" {
+		t.Errorf("EndedAt = %s, %s, want the matching PauseEnd", got.Recent[0].EndedAt, got.Recent[1].EndedAt)
+	}
+}
+
+func TestPauseStatsShortHistory(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      debug.GCStats
+		wantCount  int
+		wantMax    float64
+		wantRecent []GCPause
+	}{
+		{
+			name:       "no GC yet",
+			wantRecent: []GCPause{},
+		},
+		{
+			name: "one pause",
+			stats: debug.GCStats{
+				Pause:    []time.Duration{3 * time.Millisecond},
+				PauseEnd: []time.Time{time.Date(2025, 1, 1, 0, 0, 0, 500, time.UTC)},
+			},
+			wantCount:  1,
+			wantMax:    0.003,
+			wantRecent: []GCPause{{EndedAt: "2025-01-01T00:00:00.0000005Z", Pause: 0.003}},
+		},
+		{
+			name:       "missing end times",
+			stats:      debug.GCStats{Pause: []time.Duration{time.Millisecond, 2 * time.Millisecond}},
+			wantCount:  2,
+			wantMax:    0.002,
+			wantRecent: []GCPause{{Pause: 0.001}, {Pause: 0.002}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pauseStats(&tt.stats)
+			if got.Count != tt.wantCount || got.Max != tt.wantMax {
+				t.Errorf("count %d, max %g, want %d, %g", got.Count, got.Max, tt.wantCount, tt.wantMax)
+			}
+			if len(got.Recent) != len(tt.wantRecent) {
+				t.Fatalf("Recent = %+v, want %+v", got.Recent, tt.wantRecent)
+			}
+			for i := range got.Recent {
+				if got.Recent[i] != tt.wantRecent[i] {
+					t.Errorf("Recent[%d] = %+v, want %+v", i, got.Recent[i], tt.wantRecent[i])
+				}
+			}
+		})
+	}
+}
+
+// The memory classes partition everything the runtime has mapped.
+func TestCollectBreakdownSumsToSys(t *testing.T) {
+	info, err := Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := info.Breakdown
+	sum := b.HeapObjects + b.HeapUnused + b.HeapFree + b.HeapReleased + b.StackInUse + b.OSStacks +
+		b.MSpanInUse + b.MSpanFree + b.MCacheInUse + b.MCacheFree + b.GCMetadata + b.ProfilingBuckets + b.Other
+	if sum != b.Sys {
+		t.Errorf("memory classes sum to %d, want sys_bytes %d", sum, b.Sys)
+	}
+	if info.HeapInUse != b.HeapObjects+b.HeapUnused || info.HeapAllocated != b.HeapObjects {
+		t.Errorf("heap in use %d, allocated %d, want them derived from the breakdown %+v", info.HeapInUse, info.HeapAllocated, b)
+	}
+	if b.Retained+b.HeapReleased != b.Sys {
+		t.Errorf("retained %d + released %d != sys %d", b.Retained, b.HeapReleased, b.Sys)
+	}
+}
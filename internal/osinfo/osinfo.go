@@ -0,0 +1,182 @@
+package osinfo
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OSInfo holds process-level statistics from the Linux /proc filesystem.
+type OSInfo struct {
+	PID                    int     `json:"pid"`
+	RSS                    uint64  `json:"rss_bytes"`
+	VSZ                    uint64  `json:"vsz_bytes"`
+	UserCPU                float64 `json:"user_cpu_seconds"`
+	SystemCPU              float64 `json:"system_cpu_seconds"`
+	OpenFDs                *int    `json:"open_fds"`
+	MaxFDs                 *uint64 `json:"max_fds"`
+	Threads                int     `json:"threads"`
+	VoluntaryCtxSwitches   uint64  `json:"voluntary_ctxt_switches"`
+	InvoluntaryCtxSwitches uint64  `json:"involuntary_ctxt_switches"`
+}
+
+// clockTicks is USER_HZ, the unit of CPU times in /proc/<pid>/stat. It is 100 on
+// every mainstream Linux architecture and cannot be queried without cgo.
+const clockTicks = 100
+
+// Collect reads statistics for the current process. It returns nil when /proc is not available.
+func Collect() (*OSInfo, error) {
+	return collect("/proc/self", os.Getpid())
+}
+
+// CollectPID reads statistics for another process, which needs no agent but
+// requires permission to read its /proc entries.
+func CollectPID(pid int) (*OSInfo, error) {
+	info, err := collect(filepath.Join("/proc", strconv.Itoa(pid)), pid)
+	if err == nil && info == nil {
+		return nil, fmt.Errorf("no /proc entry for pid %d", pid)
+	}
+	return info, err
+}
+
+func CollectJSON() ([]byte, error) {
+	info, err := Collect()
+	if err != nil {
+		return nil, err
+	}
+	if info == nil {
+		return nil, errors.New("process statistics require the /proc filesystem (Linux)")
+	}
+	return json.Marshal(info)
+}
+
+func CollectPIDJSON(pid int) ([]byte, error) {
+	info, err := CollectPID(pid)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
+
+func collect(dir string, pid int) (*OSInfo, error) {
+	status, err := readStatus(filepath.Join(dir, "status"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	info := &OSInfo{
+		PID:                    pid,
+		RSS:                    status["VmRSS"] * 1024,
+		VSZ:                    status["VmSize"] * 1024,
+		Threads:                int(status["Threads"]),
+		VoluntaryCtxSwitches:   status["voluntary_ctxt_switches"],
+		InvoluntaryCtxSwitches: status["nonvoluntary_ctxt_switches"],
+	}
+
+	utime, stime, err := readCPUTimes(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	info.UserCPU = float64(utime) / clockTicks
+	info.SystemCPU = float64(stime) / clockTicks
+
+	// Listing another user's descriptors needs ptrace access, which the other
+	// fields do not; leave the count unset rather than failing the collection.
+	if fds, err := os.ReadDir(filepath.Join(dir, "fd")); err == nil {
+		n := len(fds)
+		info.OpenFDs = &n
+	}
+
+	info.MaxFDs, err = readMaxOpenFiles(filepath.Join(dir, "limits"))
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// readStatus parses the numeric fields of /proc/<pid>/status, e.g. "VmRSS:  1420 kB".
+func readStatus(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			values[key] = v
+		}
+	}
+	return values, scanner.Err()
+}
+
+// readCPUTimes returns utime and stime (fields 14 and 15) from /proc/<pid>/stat in clock ticks.
+// The command name in field 2 may contain spaces, so fields are counted after its closing parenthesis.
+func readCPUTimes(path string) (uint64, uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return 0, 0, fmt.Errorf("malformed %s", path)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 13 {
+		return 0, 0, fmt.Errorf("malformed %s", path)
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return utime, stime, nil
+}
+
+// readMaxOpenFiles returns the soft RLIMIT_NOFILE from /proc/<pid>/limits; nil when unlimited.
+func readMaxOpenFiles(path string) (*uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) == 0 || fields[0] == "unlimited" {
+			return nil, nil
+		}
+		limit, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &limit, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,133 @@
+package osinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const (
+	fakeStatus = "Name:\tserver\nVmSize:\t  204800 kB\nVmRSS:\t   10240 kB\nThreads:\t8\nvoluntary_ctxt_switches:\t120\nnonvoluntary_ctxt_switches:\t7\n"
+	fakeStat   = "4242 (my server) S 1 4242 4242 0 -1 4194560 1000 0 0 0 250 75 0 0 20 0 8 0 100 204800000 2560\n"
+	fakeLimits = "Limit                     Soft Limit           Hard Limit           Units     \nMax open files            1024                 4096                 files     \n"
+)
+
+// fakeProc writes a /proc/<pid> directory with the given files and, when fds
+// is non-negative, an fd directory holding that many entries.
+func fakeProc(t *testing.T, files map[string]string, fds int) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fds >= 0 {
+		if err := os.Mkdir(filepath.Join(dir, "fd"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for i := range fds {
+			if err := os.WriteFile(filepath.Join(dir, "fd", strconv.Itoa(i)), nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return dir
+}
+
+func TestCollect(t *testing.T) {
+	files := map[string]string{"status": fakeStatus, "stat": fakeStat, "limits": fakeLimits}
+	info, err := collect(fakeProc(t, files, 3), 4242)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PID != 4242 || info.RSS != 10240*1024 || info.VSZ != 204800*1024 || info.Threads != 8 {
+		t.Errorf("collect() = %+v, want the status fields", info)
+	}
+	if info.VoluntaryCtxSwitches != 120 || info.InvoluntaryCtxSwitches != 7 {
+		t.Errorf("context switches = %d/%d, want 120/7", info.VoluntaryCtxSwitches, info.InvoluntaryCtxSwitches)
+	}
+	if info.UserCPU != 2.5 || info.SystemCPU != 0.75 {
+		t.Errorf("CPU = %v/%v, want 2.5/0.75", info.UserCPU, info.SystemCPU)
+	}
+	if info.OpenFDs == nil || *info.OpenFDs != 3 {
+		t.Errorf("OpenFDs = %v, want 3", info.OpenFDs)
+	}
+	if info.MaxFDs == nil || *info.MaxFDs != 1024 {
+		t.Errorf("MaxFDs = %v, want 1024", info.MaxFDs)
+	}
+}
+
+// Another user's fd directory cannot be listed; the other fields are still reported.
+func TestCollectUnreadableFDs(t *testing.T) {
+	files := map[string]string{"status": fakeStatus, "stat": fakeStat, "limits": fakeLimits}
+	info, err := collect(fakeProc(t, files, -1), 4242)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.OpenFDs != nil {
+		t.Errorf("OpenFDs = %d, want nil", *info.OpenFDs)
+	}
+	if info.RSS != 10240*1024 || info.UserCPU != 2.5 || info.MaxFDs == nil {
+		t.Errorf("collect() = %+v, want the remaining fields", info)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantNil bool
+	}{
+		{"no proc entry", map[string]string{}, true},
+		{"missing stat", map[string]string{"status": fakeStatus, "limits": fakeLimits}, false},
+		{"malformed stat", map[string]string{"status": fakeStatus, "stat": "4242 server S 1", "limits": fakeLimits}, false},
+		{"missing limits", map[string]string{"status": fakeStatus, "stat": fakeStat}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := collect(fakeProc(t, tt.files, 0), 4242)
+			if tt.wantNil {
+				if info != nil || err != nil {
+					t.Errorf("collect() = %+v, %v, want nil, nil", info, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("collect() = %+v, want an error", info)
+			}
+		})
+	}
+}
+
+func TestReadMaxOpenFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits string
+		want   *uint64
+	}{
+		{"limited", fakeLimits, ptr(uint64(1024))},
+		{"unlimited", "Max open files            unlimited            unlimited            files     \n", nil},
+		{"absent", "Max processes             63704                63704                processes \n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "limits")
+			if err := os.WriteFile(path, []byte(tt.limits), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readMaxOpenFiles(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("readMaxOpenFiles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
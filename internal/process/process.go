@@ -0,0 +1,55 @@
+package process
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/Aldiwildan77/inspectd/internal/buildinfo"
+	"github.com/Aldiwildan77/inspectd/internal/runtimeinfo"
+)
+
+type ProcessInfo struct {
+	PID        int     `json:"pid"`
+	Executable string  `json:"executable"`
+	GoVersion  string  `json:"go_version"`
+	Uptime     float64 `json:"uptime_seconds"`
+	MainModule string  `json:"main_module"`
+}
+
+func Collect() (*ProcessInfo, error) {
+	runtimeInfo, err := runtimeinfo.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	// The executable may have been deleted or replaced since start; report what we can.
+	executable, _ := os.Executable()
+
+	buildInfo, err := buildinfo.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	var mainModule string
+	if buildInfo != nil {
+		mainModule = buildInfo.Main.Path
+	}
+
+	info := &ProcessInfo{
+		PID:        os.Getpid(),
+		Executable: executable,
+		GoVersion:  runtimeInfo.GoVersion,
+		Uptime:     runtimeInfo.Uptime,
+		MainModule: mainModule,
+	}
+
+	return info, nil
+}
+
+func CollectJSON() ([]byte, error) {
+	info, err := Collect()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
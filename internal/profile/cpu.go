@@ -0,0 +1,196 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/pprof"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultCPUDuration = 10 * time.Second
+	DefaultTop         = 20
+
+	// MaxCPUDuration bounds how long the CPU profiler stays on in the target process.
+	MaxCPUDuration = 5 * time.Minute
+)
+
+type CPUOptions struct {
+	Duration time.Duration
+	Top      int
+	// Raw includes the gzipped pprof profile in the result.
+	Raw bool
+}
+
+type CPUProfile struct {
+	Duration      float64        `json:"duration_seconds"`
+	Samples       int64          `json:"samples"`
+	CPU           float64        `json:"cpu_seconds"`
+	TopFlat       []FunctionCost `json:"top_flat"`
+	TopCumulative []FunctionCost `json:"top_cumulative"`
+	PProf         []byte         `json:"pprof,omitempty"`
+}
+
+// FunctionCost is the CPU time spent in a function itself (flat) and in the
+// function plus everything it called (cumulative).
+type FunctionCost struct {
+	Function  string  `json:"function"`
+	File      string  `json:"file"`
+	FlatCount int64   `json:"flat_samples"`
+	Flat      float64 `json:"flat_seconds"`
+	FlatPct   float64 `json:"flat_percent"`
+	CumCount  int64   `json:"cum_samples"`
+	Cum       float64 `json:"cum_seconds"`
+	CumPct    float64 `json:"cum_percent"`
+}
+
+// CollectCPU runs the CPU profiler for the duration and summarizes the
+// result. Cancelling ctx stops the profile early and returns ctx.Err().
+func CollectCPU(ctx context.Context, opts CPUOptions) (*CPUProfile, error) {
+	if opts.Duration <= 0 {
+		opts.Duration = DefaultCPUDuration
+	}
+	if opts.Duration > MaxCPUDuration {
+		return nil, fmt.Errorf("duration %s exceeds the maximum of %s", opts.Duration, MaxCPUDuration)
+	}
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	timer := time.NewTimer(opts.Duration)
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		timer.Stop()
+	}
+	pprof.StopCPUProfile()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	p, err := Parse(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	result := summarizeCPU(p, opts.Top)
+	if opts.Raw {
+		result.PProf = buf.Bytes()
+	}
+	return result, nil
+}
+
+func CollectCPUJSON(ctx context.Context, opts CPUOptions) ([]byte, error) {
+	result, err := CollectCPU(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+func summarizeCPU(p *Profile, top int) *CPUProfile {
+	result := &CPUProfile{
+		Duration:      time.Duration(p.DurationNanos).Seconds(),
+		TopFlat:       []FunctionCost{},
+		TopCumulative: []FunctionCost{},
+	}
+
+	countIdx, cpuIdx := p.ValueIndex("samples"), p.ValueIndex("cpu")
+	if countIdx < 0 || cpuIdx < 0 {
+		return result
+	}
+
+	type cost struct {
+		file                string
+		flat, cum           int64 // nanoseconds
+		flatCount, cumCount int64
+	}
+	costs := make(map[string]*cost)
+	var total int64
+
+	for _, s := range p.Samples {
+		count, nanos := s.Values[countIdx], s.Values[cpuIdx]
+		result.Samples += count
+		total += nanos
+
+		// Recursive functions appear several times in a stack but count once towards cum.
+		seen := make(map[string]bool, len(s.Stack))
+		for i, f := range s.Stack {
+			c, ok := costs[f.Function]
+			if !ok {
+				c = &cost{file: f.File}
+				costs[f.Function] = c
+			}
+			if i == 0 {
+				c.flat += nanos
+				c.flatCount += count
+			}
+			if !seen[f.Function] {
+				seen[f.Function] = true
+				c.cum += nanos
+				c.cumCount += count
+			}
+		}
+	}
+	result.CPU = time.Duration(total).Seconds()
+
+	functions := make([]FunctionCost, 0, len(costs))
+	for name, c := range costs {
+		functions = append(functions, FunctionCost{
+			Function:  name,
+			File:      c.file,
+			Flat:      time.Duration(c.flat).Seconds(),
+			FlatPct:   percent(c.flat, total),
+			Cum:       time.Duration(c.cum).Seconds(),
+			CumPct:    percent(c.cum, total),
+			FlatCount: c.flatCount,
+			CumCount:  c.cumCount,
+		})
+	}
+
+	result.TopFlat = topN(functions, top, func(a, b FunctionCost) bool {
+		if a.FlatCount != b.FlatCount {
+			return a.FlatCount > b.FlatCount
+		}
+		if a.CumCount != b.CumCount {
+			return a.CumCount > b.CumCount
+		}
+		return a.Function < b.Function
+	})
+	result.TopCumulative = topN(functions, top, func(a, b FunctionCost) bool {
+		if a.CumCount != b.CumCount {
+			return a.CumCount > b.CumCount
+		}
+		if a.FlatCount != b.FlatCount {
+			return a.FlatCount > b.FlatCount
+		}
+		return a.Function < b.Function
+	})
+	return result
+}
+
+// topN returns the first n entries of a sorted copy of items.
+func topN[T any](items []T, n int, less func(a, b T) bool) []T {
+	// Never nil, so empty results encode as [] rather than null.
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.Slice(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func percent(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
@@ -0,0 +1,81 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/schema"
+)
+
+var cpuSampleTypes = []ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}}
+
+func TestSummarizeCPUEmpty(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *Profile
+	}{
+		{"no samples", &Profile{SampleTypes: cpuSampleTypes}},
+		{"no sample types", &Profile{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(summarizeCPU(tt.profile, DefaultTop))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{`"top_flat":[]`, `"top_cumulative":[]`} {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("output %s does not contain %s", data, want)
+				}
+			}
+			if err := schema.Of(CPUProfile{}).Validate(data); err != nil {
+				t.Errorf("output does not match schema: %v", err)
+			}
+		})
+	}
+}
+
+func TestSummarizeCPU(t *testing.T) {
+	main := Frame{Function: "main.main", File: "main.go", Line: 10}
+	work := Frame{Function: "main.work", File: "main.go", Line: 20}
+	recurse := Frame{Function: "main.recurse", File: "main.go", Line: 30}
+	p := &Profile{
+		SampleTypes: cpuSampleTypes,
+		Samples: []Sample{
+			{Stack: []Frame{work, main}, Values: []int64{3, 30}},
+			{Stack: []Frame{recurse, recurse, main}, Values: []int64{1, 10}},
+		},
+	}
+
+	result := summarizeCPU(p, 2)
+	if result.Samples != 4 {
+		t.Errorf("Samples = %d, want 4", result.Samples)
+	}
+	if len(result.TopFlat) != 2 || result.TopFlat[0].Function != "main.work" || result.TopFlat[0].FlatCount != 3 {
+		t.Errorf("TopFlat = %+v, want main.work with 3 samples first", result.TopFlat)
+	}
+	if len(result.TopCumulative) != 2 || result.TopCumulative[0].Function != "main.main" || result.TopCumulative[0].CumPct != 100 {
+		t.Errorf("TopCumulative = %+v, want main.main at 100%% first", result.TopCumulative)
+	}
+	if got := result.TopCumulative[1]; got.Function != "main.work" {
+		t.Errorf("TopCumulative[1] = %s, want main.work", got.Function)
+	}
+	for _, f := range result.TopFlat {
+		if f.Function == "main.recurse" && f.CumCount != 1 {
+			t.Errorf("recursive function counted %d times towards cum, want 1", f.CumCount)
+		}
+	}
+}
+
+func TestCollectCPUIdleMatchesSchema(t *testing.T) {
+	data, err := CollectCPUJSON(context.Background(), CPUOptions{Duration: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Of(CPUProfile{}).Validate(data); err != nil {
+		t.Errorf("output %s does not match schema: %v", data, err)
+	}
+}
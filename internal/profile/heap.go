@@ -0,0 +1,207 @@
+package profile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+)
+
+const (
+	SortInUse = "inuse"
+	SortAlloc = "alloc"
+)
+
+type HeapOptions struct {
+	Top int
+	// SortBy ranks entries by in-use (default) or total allocated bytes.
+	SortBy string
+	// GC runs a garbage collection first so in-use values are current
+	// instead of reflecting the last completed cycle.
+	GC bool
+	// Raw includes the gzipped pprof profile in the result.
+	Raw bool
+}
+
+type HeapProfile struct {
+	InUseBytes   int64       `json:"inuse_bytes"`
+	InUseObjects int64       `json:"inuse_objects"`
+	AllocBytes   int64       `json:"alloc_bytes"`
+	AllocObjects int64       `json:"alloc_objects"`
+	TopFunctions []HeapUsage `json:"top_functions"`
+	TopSites     []HeapUsage `json:"top_sites"`
+	PProf        []byte      `json:"pprof,omitempty"`
+}
+
+// HeapUsage attributes heap memory to the allocating function, or to the
+// allocation site (function and line) when Line is set. Both are the first frame
+// outside the standard library, see allocationSite. Values are the runtime's
+// estimates scaled up from its sampled allocations.
+type HeapUsage struct {
+	Function     string  `json:"function"`
+	File         string  `json:"file"`
+	Line         int     `json:"line,omitempty"`
+	InUseBytes   int64   `json:"inuse_bytes"`
+	InUseObjects int64   `json:"inuse_objects"`
+	AllocBytes   int64   `json:"alloc_bytes"`
+	AllocObjects int64   `json:"alloc_objects"`
+	Frames       []Frame `json:"frames,omitempty"`
+}
+
+func CollectHeap(opts HeapOptions) (*HeapProfile, error) {
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = SortInUse
+	case SortInUse, SortAlloc:
+	default:
+		return nil, fmt.Errorf("unknown sort order: %s (expected %s or %s)", opts.SortBy, SortInUse, SortAlloc)
+	}
+
+	if opts.GC {
+		runtime.GC()
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("heap").WriteTo(&buf, 0); err != nil {
+		return nil, fmt.Errorf("failed to read heap profile: %w", err)
+	}
+
+	p, err := Parse(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	result := summarizeHeap(p, opts)
+	if opts.Raw {
+		result.PProf = buf.Bytes()
+	}
+	return result, nil
+}
+
+func CollectHeapJSON(opts HeapOptions) ([]byte, error) {
+	result, err := CollectHeap(opts)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+func summarizeHeap(p *Profile, opts HeapOptions) *HeapProfile {
+	result := &HeapProfile{
+		TopFunctions: []HeapUsage{},
+		TopSites:     []HeapUsage{},
+	}
+
+	allocObjectsIdx, allocSpaceIdx := p.ValueIndex("alloc_objects"), p.ValueIndex("alloc_space")
+	inuseObjectsIdx, inuseSpaceIdx := p.ValueIndex("inuse_objects"), p.ValueIndex("inuse_space")
+	if allocObjectsIdx < 0 || allocSpaceIdx < 0 || inuseObjectsIdx < 0 || inuseSpaceIdx < 0 {
+		return result
+	}
+
+	functions := make(map[string]*HeapUsage)
+	sites := make(map[Frame]*HeapUsage)
+	heaviest := make(map[Frame]int64)
+
+	for _, s := range p.Samples {
+		if len(s.Stack) == 0 {
+			continue
+		}
+		usage := HeapUsage{
+			InUseBytes:   s.Values[inuseSpaceIdx],
+			InUseObjects: s.Values[inuseObjectsIdx],
+			AllocBytes:   s.Values[allocSpaceIdx],
+			AllocObjects: s.Values[allocObjectsIdx],
+		}
+		result.InUseBytes += usage.InUseBytes
+		result.InUseObjects += usage.InUseObjects
+		result.AllocBytes += usage.AllocBytes
+		result.AllocObjects += usage.AllocObjects
+
+		caller := allocationSite(s.Stack)
+
+		fn, ok := functions[caller.Function]
+		if !ok {
+			fn = &HeapUsage{Function: caller.Function, File: caller.File}
+			functions[caller.Function] = fn
+		}
+		fn.add(usage)
+
+		site, ok := sites[caller]
+		if !ok {
+			site = &HeapUsage{Function: caller.Function, File: caller.File, Line: caller.Line}
+			sites[caller] = site
+		}
+		site.add(usage)
+
+		// Keep the stack that contributes the most to the site as its example.
+		weight := usage.sortKey(opts.SortBy)
+		if site.Frames == nil || weight > heaviest[caller] {
+			heaviest[caller] = weight
+			site.Frames = s.Stack
+		}
+	}
+
+	less := func(a, b HeapUsage) bool {
+		if ka, kb := a.sortKey(opts.SortBy), b.sortKey(opts.SortBy); ka != kb {
+			return ka > kb
+		}
+		if a.Function != b.Function {
+			return a.Function < b.Function
+		}
+		return a.Line < b.Line
+	}
+	result.TopFunctions = topN(values(functions), opts.Top, less)
+	result.TopSites = topN(values(sites), opts.Top, less)
+	return result
+}
+
+// allocationSite returns the first frame outside the standard library, so memory
+// allocated on the caller's behalf (runtime.malg, bytes.growSlice,
+// compress/flate.NewWriter) is charged to the code that asked for it. The leaf
+// frame is used when the whole stack is in the standard library.
+func allocationSite(stack []Frame) Frame {
+	for _, f := range stack {
+		if !isStandard(f.Function) {
+			return f
+		}
+	}
+	return stack[0]
+}
+
+// isStandard reports whether function belongs to the standard library. As for
+// the go command, that is a package path whose first element has no dot;
+// package main is the program itself.
+func isStandard(function string) bool {
+	if first, _, ok := strings.Cut(function, "/"); ok {
+		return !strings.Contains(first, ".")
+	}
+	pkg, _, _ := strings.Cut(function, ".")
+	return pkg != "" && pkg != "main"
+}
+
+func (u *HeapUsage) add(other HeapUsage) {
+	u.InUseBytes += other.InUseBytes
+	u.InUseObjects += other.InUseObjects
+	u.AllocBytes += other.AllocBytes
+	u.AllocObjects += other.AllocObjects
+}
+
+func (u HeapUsage) sortKey(sortBy string) int64 {
+	if sortBy == SortAlloc {
+		return u.AllocBytes
+	}
+	return u.InUseBytes
+}
+
+func values[K comparable](m map[K]*HeapUsage) []HeapUsage {
+	out := make([]HeapUsage, 0, len(m))
+	for _, v := range m {
+		out = append(out, *v)
+	}
+	return out
+}
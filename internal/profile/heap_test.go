@@ -0,0 +1,145 @@
+package profile
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Aldiwildan77/inspectd/internal/schema"
+)
+
+var heapSampleTypes = []ValueType{
+	{Type: "alloc_objects", Unit: "count"},
+	{Type: "alloc_space", Unit: "bytes"},
+	{Type: "inuse_objects", Unit: "count"},
+	{Type: "inuse_space", Unit: "bytes"},
+}
+
+func TestSummarizeHeapEmpty(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *Profile
+	}{
+		{"no samples", &Profile{SampleTypes: heapSampleTypes}},
+		{"no sample types", &Profile{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(summarizeHeap(tt.profile, HeapOptions{Top: DefaultTop, SortBy: SortInUse}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{`"top_functions":[]`, `"top_sites":[]`} {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("output %s does not contain %s", data, want)
+				}
+			}
+			if err := schema.Of(HeapProfile{}).Validate(data); err != nil {
+				t.Errorf("output does not match schema: %v", err)
+			}
+		})
+	}
+}
+
+func TestSummarizeHeapSort(t *testing.T) {
+	a := Frame{Function: "main.a", File: "main.go", Line: 1}
+	b := Frame{Function: "main.b", File: "main.go", Line: 2}
+	p := &Profile{
+		SampleTypes: heapSampleTypes,
+		Samples: []Sample{
+			// alloc_objects, alloc_space, inuse_objects, inuse_space
+			{Stack: []Frame{a}, Values: []int64{1, 100, 1, 100}},
+			{Stack: []Frame{b}, Values: []int64{10, 1000, 0, 0}},
+		},
+	}
+
+	tests := []struct {
+		sortBy string
+		first  string
+	}{
+		{SortInUse, "main.a"},
+		{SortAlloc, "main.b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy, func(t *testing.T) {
+			result := summarizeHeap(p, HeapOptions{Top: 1, SortBy: tt.sortBy})
+			if result.AllocBytes != 1100 || result.InUseBytes != 100 {
+				t.Errorf("totals = %d alloc, %d in use, want 1100, 100", result.AllocBytes, result.InUseBytes)
+			}
+			if len(result.TopSites) != 1 || result.TopSites[0].Function != tt.first {
+				t.Errorf("TopSites = %+v, want %s first", result.TopSites, tt.first)
+			}
+		})
+	}
+}
+
+func TestSummarizeHeapAttribution(t *testing.T) {
+	growslice := Frame{Function: "runtime.growslice", File: "/go/src/runtime/slice.go", Line: 177}
+	bufferGrow := Frame{Function: "bytes.growSlice", File: "/go/src/bytes/buffer.go", Line: 249}
+	write := Frame{Function: "bytes.(*Buffer).Write", File: "/go/src/bytes/buffer.go", Line: 179}
+	newWriter := Frame{Function: "compress/flate.NewWriter", File: "/go/src/compress/flate/deflate.go", Line: 666}
+	malg := Frame{Function: "runtime.malg", File: "/go/src/runtime/proc.go", Line: 4750}
+	newproc := Frame{Function: "runtime.newproc1", File: "/go/src/runtime/proc.go", Line: 4800}
+	encode := Frame{Function: "github.com/acme/app/codec.(*Encoder).Encode", File: "/src/codec/encode.go", Line: 31}
+	compress := Frame{Function: "github.com/acme/app/codec.compress", File: "/src/codec/gzip.go", Line: 12}
+	run := Frame{Function: "main.run", File: "/src/main.go", Line: 20}
+
+	tests := []struct {
+		name  string
+		stack []Frame
+		want  Frame
+	}{
+		{"runtime leaf", []Frame{growslice, encode, run}, encode},
+		{"standard library helpers", []Frame{bufferGrow, write, encode, run}, encode},
+		{"standard library package", []Frame{newWriter, compress, run}, compress},
+		{"package main", []Frame{growslice, run}, run},
+		{"all standard library", []Frame{malg, newproc}, malg},
+		{"user leaf", []Frame{encode, run}, encode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Profile{
+				SampleTypes: heapSampleTypes,
+				Samples:     []Sample{{Stack: tt.stack, Values: []int64{1, 100, 1, 100}}},
+			}
+			result := summarizeHeap(p, HeapOptions{Top: DefaultTop, SortBy: SortInUse})
+			if len(result.TopFunctions) != 1 || result.TopFunctions[0].Function != tt.want.Function {
+				t.Errorf("TopFunctions = %+v, want %s", result.TopFunctions, tt.want.Function)
+			}
+			if len(result.TopSites) != 1 {
+				t.Fatalf("TopSites = %+v, want one site", result.TopSites)
+			}
+			site := result.TopSites[0]
+			if site.Function != tt.want.Function || site.Line != tt.want.Line || len(site.Frames) != len(tt.stack) {
+				t.Errorf("TopSites[0] = %+v, want %s line %d with the full stack", site, tt.want.Function, tt.want.Line)
+			}
+		})
+	}
+}
+
+// Allocations reached through different standard library helpers are charged
+// to the one call site that made them.
+func TestSummarizeHeapMergesCallers(t *testing.T) {
+	encode := Frame{Function: "github.com/acme/app/codec.(*Encoder).Encode", File: "/src/codec/encode.go", Line: 31}
+	p := &Profile{
+		SampleTypes: heapSampleTypes,
+		Samples: []Sample{
+			{Stack: []Frame{{Function: "runtime.growslice"}, encode}, Values: []int64{1, 100, 1, 100}},
+			{Stack: []Frame{{Function: "runtime.makeslice"}, encode}, Values: []int64{2, 300, 2, 300}},
+		},
+	}
+	result := summarizeHeap(p, HeapOptions{Top: DefaultTop, SortBy: SortInUse})
+	if len(result.TopSites) != 1 || result.TopSites[0].InUseBytes != 400 || result.TopSites[0].Frames[0].Function != "runtime.makeslice" {
+		t.Errorf("TopSites = %+v, want one site with 400 bytes and the makeslice stack", result.TopSites)
+	}
+}
+
+func TestCollectHeapMatchesSchema(t *testing.T) {
+	data, err := CollectHeapJSON(HeapOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := schema.Of(HeapProfile{}).Validate(data); err != nil {
+		t.Errorf("output does not match schema: %v", err)
+	}
+}
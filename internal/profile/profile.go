@@ -0,0 +1,345 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Profile is the subset of a pprof profile inspectd summarizes. Stacks are
+// resolved to frames, leaf first; inlined calls appear as separate frames.
+type Profile struct {
+	SampleTypes   []ValueType
+	Samples       []Sample
+	DurationNanos int64
+	PeriodType    ValueType
+	Period        int64
+}
+
+type ValueType struct {
+	Type string
+	Unit string
+}
+
+type Sample struct {
+	Stack  []Frame
+	Values []int64
+}
+
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// ValueIndex returns the index of the sample value with the given type, or -1.
+func (p *Profile) ValueIndex(typ string) int {
+	for i, st := range p.SampleTypes {
+		if st.Type == typ {
+			return i
+		}
+	}
+	return -1
+}
+
+// Parse decodes a pprof profile as written by runtime/pprof, gzipped or not.
+func Parse(data []byte) (*Profile, error) {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %w", err)
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress profile: %w", err)
+		}
+	}
+
+	var raw rawProfile
+	if err := raw.decode(data); err != nil {
+		return nil, fmt.Errorf("failed to decode profile: %w", err)
+	}
+	return raw.resolve()
+}
+
+// Wire-level messages from profile.proto, with string table indexes and IDs unresolved.
+type rawProfile struct {
+	sampleTypes   []rawValueType
+	samples       []rawSample
+	locations     map[uint64][]rawLine
+	functions     map[uint64]rawFunction
+	strings       []string
+	durationNanos int64
+	periodType    rawValueType
+	period        int64
+}
+
+type rawValueType struct{ typ, unit int64 }
+
+type rawSample struct {
+	locationIDs []uint64
+	values      []int64
+}
+
+type rawLine struct {
+	functionID uint64
+	line       int64
+}
+
+type rawFunction struct{ name, filename int64 }
+
+func (p *rawProfile) decode(data []byte) error {
+	p.locations = make(map[uint64][]rawLine)
+	p.functions = make(map[uint64]rawFunction)
+
+	return decodeMessage(data, func(field int, wire int, value uint64, payload []byte) error {
+		switch field {
+		case 1:
+			vt, err := decodeValueType(payload)
+			if err != nil {
+				return err
+			}
+			p.sampleTypes = append(p.sampleTypes, vt)
+		case 2:
+			var s rawSample
+			err := decodeMessage(payload, func(field int, wire int, value uint64, payload []byte) error {
+				switch field {
+				case 1:
+					return appendVarints(&s.locationIDs, wire, value, payload)
+				case 2:
+					var values []uint64
+					if err := appendVarints(&values, wire, value, payload); err != nil {
+						return err
+					}
+					for _, v := range values {
+						s.values = append(s.values, int64(v))
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			p.samples = append(p.samples, s)
+		case 4:
+			var id uint64
+			var lines []rawLine
+			err := decodeMessage(payload, func(field int, wire int, value uint64, payload []byte) error {
+				switch field {
+				case 1:
+					id = value
+				case 4:
+					var l rawLine
+					err := decodeMessage(payload, func(field int, wire int, value uint64, payload []byte) error {
+						switch field {
+						case 1:
+							l.functionID = value
+						case 2:
+							l.line = int64(value)
+						}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+					lines = append(lines, l)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			p.locations[id] = lines
+		case 5:
+			var id uint64
+			var f rawFunction
+			err := decodeMessage(payload, func(field int, wire int, value uint64, payload []byte) error {
+				switch field {
+				case 1:
+					id = value
+				case 2:
+					f.name = int64(value)
+				case 4:
+					f.filename = int64(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			p.functions[id] = f
+		case 6:
+			p.strings = append(p.strings, string(payload))
+		case 10:
+			p.durationNanos = int64(value)
+		case 11:
+			vt, err := decodeValueType(payload)
+			if err != nil {
+				return err
+			}
+			p.periodType = vt
+		case 12:
+			p.period = int64(value)
+		}
+		return nil
+	})
+}
+
+func decodeValueType(data []byte) (rawValueType, error) {
+	var vt rawValueType
+	err := decodeMessage(data, func(field int, wire int, value uint64, payload []byte) error {
+		switch field {
+		case 1:
+			vt.typ = int64(value)
+		case 2:
+			vt.unit = int64(value)
+		}
+		return nil
+	})
+	return vt, err
+}
+
+func (p *rawProfile) resolve() (*Profile, error) {
+	str := func(i int64) (string, error) {
+		if i < 0 || i >= int64(len(p.strings)) {
+			return "", fmt.Errorf("string index %d out of range", i)
+		}
+		return p.strings[i], nil
+	}
+	valueType := func(vt rawValueType) (ValueType, error) {
+		typ, err := str(vt.typ)
+		if err != nil {
+			return ValueType{}, err
+		}
+		unit, err := str(vt.unit)
+		if err != nil {
+			return ValueType{}, err
+		}
+		return ValueType{Type: typ, Unit: unit}, nil
+	}
+
+	out := &Profile{
+		SampleTypes:   make([]ValueType, 0, len(p.sampleTypes)),
+		Samples:       make([]Sample, 0, len(p.samples)),
+		DurationNanos: p.durationNanos,
+		Period:        p.period,
+	}
+
+	var err error
+	if out.PeriodType, err = valueType(p.periodType); err != nil {
+		return nil, err
+	}
+	for _, vt := range p.sampleTypes {
+		resolved, err := valueType(vt)
+		if err != nil {
+			return nil, err
+		}
+		out.SampleTypes = append(out.SampleTypes, resolved)
+	}
+
+	// Locations are shared between samples, so resolve each one once.
+	frames := make(map[uint64][]Frame, len(p.locations))
+	for id, lines := range p.locations {
+		for _, l := range lines {
+			f := p.functions[l.functionID]
+			name, err := str(f.name)
+			if err != nil {
+				return nil, err
+			}
+			file, err := str(f.filename)
+			if err != nil {
+				return nil, err
+			}
+			frames[id] = append(frames[id], Frame{Function: name, File: file, Line: int(l.line)})
+		}
+	}
+
+	for _, s := range p.samples {
+		sample := Sample{Values: s.values}
+		for _, id := range s.locationIDs {
+			sample.Stack = append(sample.Stack, frames[id]...)
+		}
+		out.Samples = append(out.Samples, sample)
+	}
+
+	return out, nil
+}
+
+// Protobuf wire types used by profile.proto.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated message")
+
+// decodeMessage calls fn for every field in a protobuf message. Varint
+// fields pass their value; length-delimited fields pass their payload.
+func decodeMessage(data []byte, fn func(field int, wire int, value uint64, payload []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		field, wire := int(key>>3), int(key&7)
+		var value uint64
+		var payload []byte
+		switch wire {
+		case wireVarint:
+			value, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			data = data[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errTruncated
+			}
+			payload = data[n : n+int(length)]
+			data = data[n+int(length):]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			data = data[4:]
+		default:
+			return fmt.Errorf("unsupported wire type %d", wire)
+		}
+
+		if err := fn(field, wire, value, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendVarints appends a repeated varint field, which runtime/pprof encodes
+// packed for more than two values and unpacked otherwise.
+func appendVarints(dst *[]uint64, wire int, value uint64, payload []byte) error {
+	if wire == wireVarint {
+		*dst = append(*dst, value)
+		return nil
+	}
+	for len(payload) > 0 {
+		v, n := binary.Uvarint(payload)
+		if n <= 0 {
+			return errTruncated
+		}
+		*dst = append(*dst, v)
+		payload = payload[n:]
+	}
+	return nil
+}
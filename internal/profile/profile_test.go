@@ -0,0 +1,305 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Minimal protobuf encoding for building profiles by hand.
+
+func key(field, wire int) []byte {
+	return binary.AppendUvarint(nil, uint64(field)<<3|uint64(wire))
+}
+
+func varint(field int, v uint64) []byte {
+	return binary.AppendUvarint(key(field, wireVarint), v)
+}
+
+func message(field int, parts ...[]byte) []byte {
+	payload := bytes.Join(parts, nil)
+	return append(binary.AppendUvarint(key(field, wireBytes), uint64(len(payload))), payload...)
+}
+
+func packed(field int, values ...uint64) []byte {
+	var payload []byte
+	for _, v := range values {
+		payload = binary.AppendUvarint(payload, v)
+	}
+	return message(field, payload)
+}
+
+func str(s string) []byte {
+	return message(6, []byte(s))
+}
+
+func profileBytes(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// testProfile has two samples over main.work -> main.main, the first with its
+// repeated fields packed and the second unpacked.
+func testProfile() []byte {
+	return profileBytes(
+		str(""), str("samples"), str("count"), str("cpu"), str("nanoseconds"),
+		str("main.main"), str("main.work"), str("/src/main.go"),
+		message(1, varint(1, 1), varint(2, 2)),
+		message(1, varint(1, 3), varint(2, 4)),
+		message(2, packed(1, 2, 1), packed(2, 3, 30000000)),
+		message(2, varint(1, 1), varint(2, 1), varint(2, 10000000)),
+		message(4, varint(1, 1), message(4, varint(1, 1), varint(2, 10))),
+		message(4, varint(1, 2), message(4, varint(1, 2), varint(2, 20)), message(4, varint(1, 1), varint(2, 11))),
+		message(5, varint(1, 1), varint(2, 5), varint(4, 7)),
+		message(5, varint(1, 2), varint(2, 6), varint(4, 7)),
+		varint(10, 1e9),
+		message(11, varint(1, 3), varint(2, 4)),
+		varint(12, 10000000),
+	)
+}
+
+func TestParse(t *testing.T) {
+	want := &Profile{
+		SampleTypes: []ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		Samples: []Sample{
+			{
+				// Location 2 has main.work inlined into main.main, so it yields two frames.
+				Stack: []Frame{
+					{Function: "main.work", File: "/src/main.go", Line: 20},
+					{Function: "main.main", File: "/src/main.go", Line: 11},
+					{Function: "main.main", File: "/src/main.go", Line: 10},
+				},
+				Values: []int64{3, 30000000},
+			},
+			{
+				Stack:  []Frame{{Function: "main.main", File: "/src/main.go", Line: 10}},
+				Values: []int64{1, 10000000},
+			},
+		},
+		DurationNanos: 1e9,
+		PeriodType:    ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:        10000000,
+	}
+
+	for name, data := range map[string][]byte{"plain": testProfile(), "gzipped": gzipped(t, testProfile())} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseSkipsUnknownFields(t *testing.T) {
+	data := profileBytes(
+		testProfile(),
+		varint(9, 123),           // time_nanos
+		message(3, varint(1, 1)), // mapping
+		key(99, wireFixed64), make([]byte, 8),
+		key(98, wireFixed32), make([]byte, 4),
+	)
+	p, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Samples) != 2 {
+		t.Errorf("got %d samples, want 2", len(p.Samples))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	full := testProfile()
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"truncated key", []byte{0x80}, "truncated"},
+		{"truncated varint", []byte{0x50, 0x80}, "truncated"},
+		{"truncated length", append(key(2, wireBytes), 0x05, 0x01), "truncated"},
+		{"truncated fixed64", append(key(99, wireFixed64), 1, 2, 3), "truncated"},
+		{"truncated fixed32", append(key(99, wireFixed32), 1), "truncated"},
+		{"truncated profile", full[:len(full)-20], "truncated"},
+		{"truncated packed values", message(2, message(2, []byte{0x80})), "truncated"},
+		{"unsupported wire type", key(1, 3), "unsupported wire type 3"},
+		{"sample type string out of range", profileBytes(str(""), message(1, varint(1, 7))), "string index 7 out of range"},
+		{"no string table", message(1), "string index 0 out of range"},
+		{"function name out of range", profileBytes(
+			str(""),
+			message(4, varint(1, 1), message(4, varint(1, 1))),
+			message(5, varint(1, 1), varint(2, 42)),
+		), "string index 42 out of range"},
+		{"bad gzip", []byte{0x1f, 0x8b, 0x00}, "failed to decompress"},
+		{"truncated gzip", gzipped(t, full)[:30], "failed to decompress"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.data)
+			if err == nil {
+				t.Fatal("Parse() succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeMessage(t *testing.T) {
+	data := profileBytes(varint(1, 300), message(2, []byte("hi")), key(3, wireFixed64), make([]byte, 8))
+
+	type field struct {
+		field, wire int
+		value       uint64
+		payload     string
+	}
+	var got []field
+	err := decodeMessage(data, func(f int, wire int, value uint64, payload []byte) error {
+		got = append(got, field{f, wire, value, string(payload)})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []field{{1, wireVarint, 300, ""}, {2, wireBytes, 0, "hi"}, {3, wireFixed64, 0, ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeMessage() fields = %+v, want %+v", got, want)
+	}
+
+	stop := errors.New("stop")
+	if err := decodeMessage(data, func(int, int, uint64, []byte) error { return stop }); err != stop {
+		t.Errorf("decodeMessage() error = %v, want the callback's error", err)
+	}
+}
+
+func TestAppendVarints(t *testing.T) {
+	var values []uint64
+	if err := appendVarints(&values, wireVarint, 7, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendVarints(&values, wireBytes, 0, binary.AppendUvarint(binary.AppendUvarint(nil, 1), 1<<40)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{7, 1, 1 << 40}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values = %v, want %v", values, want)
+	}
+	if err := appendVarints(&values, wireBytes, 0, []byte{0xff}); !errors.Is(err, errTruncated) {
+		t.Errorf("appendVarints() error = %v, want %v", err, errTruncated)
+	}
+}
+
+//go:noinline
+func spin(until time.Time) int {
+	n := 0
+	for time.Now().Before(until) {
+		for i := 0; i < 1000; i++ {
+			n += i
+		}
+	}
+	return n
+}
+
+func TestParseRuntimeCPUProfile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the CPU profiler")
+	}
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		t.Skipf("CPU profiler unavailable: %v", err)
+	}
+	spin(time.Now().Add(300 * time.Millisecond))
+	pprof.StopCPUProfile()
+
+	p, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}}
+	if !reflect.DeepEqual(p.SampleTypes, want) {
+		t.Errorf("SampleTypes = %+v, want %+v", p.SampleTypes, want)
+	}
+	if p.PeriodType != (ValueType{Type: "cpu", Unit: "nanoseconds"}) || p.Period <= 0 || p.DurationNanos <= 0 {
+		t.Errorf("period %+v %d, duration %d", p.PeriodType, p.Period, p.DurationNanos)
+	}
+	if len(p.Samples) == 0 {
+		t.Fatal("no samples")
+	}
+	found := false
+	for _, s := range p.Samples {
+		if len(s.Values) != 2 {
+			t.Fatalf("sample has %d values, want 2", len(s.Values))
+		}
+		for _, f := range s.Stack {
+			if strings.HasSuffix(f.Function, "profile.spin") && strings.HasSuffix(f.File, "profile_test.go") && f.Line > 0 {
+				found = true
+			}
+		}
+	}
+	if !found {
+		t.Error("no sample includes the spin function")
+	}
+}
+
+var heapSink [][]byte
+
+//go:noinline
+func allocate() {
+	for i := 0; i < 1000; i++ {
+		heapSink = append(heapSink, make([]byte, 4096))
+	}
+}
+
+func TestParseRuntimeHeapProfile(t *testing.T) {
+	defer func(rate int) { runtime.MemProfileRate = rate }(runtime.MemProfileRate)
+	runtime.MemProfileRate = 1
+	allocate()
+	defer func() { heapSink = nil }()
+	runtime.GC()
+
+	var buf bytes.Buffer
+	if err := pprof.WriteHeapProfile(&buf); err != nil {
+		t.Fatal(err)
+	}
+	p, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []string{"alloc_objects", "alloc_space", "inuse_objects", "inuse_space"} {
+		if p.ValueIndex(typ) < 0 {
+			t.Errorf("missing sample type %s in %+v", typ, p.SampleTypes)
+		}
+	}
+	inuse := p.ValueIndex("inuse_space")
+	var allocated int64
+	for _, s := range p.Samples {
+		if len(s.Stack) > 0 && strings.HasSuffix(s.Stack[0].Function, "profile.allocate") {
+			allocated += s.Values[inuse]
+		}
+	}
+	if allocated < 1000*4096 {
+		t.Errorf("in-use bytes attributed to allocate = %d, want at least %d", allocated, 1000*4096)
+	}
+}
@@ -0,0 +1,186 @@
+package rtmetrics
+
+import (
+	"encoding/json"
+	"math"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+)
+
+// Samples holds runtime/metrics values keyed by metric name.
+// Metrics the running Go version does not support read as zero.
+type Samples map[string]metrics.Value
+
+// Read samples the named metrics in a single runtime/metrics.Read call.
+// Unlike runtime.ReadMemStats, this does not stop the world.
+func Read(names ...string) Samples {
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+
+	out := make(Samples, len(samples))
+	for _, sample := range samples {
+		out[sample.Name] = sample.Value
+	}
+	return out
+}
+
+// Supported reports whether the running Go version provides the named metric.
+func (s Samples) Supported(name string) bool {
+	v, ok := s[name]
+	return ok && v.Kind() != metrics.KindBad
+}
+
+func (s Samples) Uint64(name string) uint64 {
+	v, ok := s[name]
+	if !ok || v.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return v.Uint64()
+}
+
+func (s Samples) Float64(name string) float64 {
+	v, ok := s[name]
+	if !ok || v.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+	return v.Float64()
+}
+
+func (s Samples) Histogram(name string) *metrics.Float64Histogram {
+	v, ok := s[name]
+	if !ok || v.Kind() != metrics.KindFloat64Histogram {
+		return nil
+	}
+	return v.Float64Histogram()
+}
+
+type Metric struct {
+	Name        string      `json:"name"`
+	Kind        string      `json:"kind"`
+	Unit        string      `json:"unit"`
+	Description string      `json:"description"`
+	Cumulative  bool        `json:"cumulative"`
+	Value       json.Number `json:"value,omitempty"`
+	Histogram   *Histogram  `json:"histogram,omitempty"`
+}
+
+// Histogram has one more boundary than counts; Counts[i] covers [Boundaries[i], Boundaries[i+1]).
+// Infinite boundaries are encoded as null since JSON has no infinity.
+type Histogram struct {
+	Boundaries []*float64 `json:"boundaries"`
+	Counts     []uint64   `json:"counts"`
+}
+
+// Collect reads every metric supported by the running Go version.
+func Collect() ([]Metric, error) {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	for i, desc := range descs {
+		samples[i].Name = desc.Name
+	}
+	metrics.Read(samples)
+
+	out := make([]Metric, 0, len(descs))
+	for i, desc := range descs {
+		m := Metric{
+			Name:        desc.Name,
+			Unit:        desc.Name[strings.LastIndex(desc.Name, ":")+1:],
+			Description: desc.Description,
+			Cumulative:  desc.Cumulative,
+		}
+
+		value := samples[i].Value
+		switch value.Kind() {
+		case metrics.KindUint64:
+			m.Kind = "uint64"
+			m.Value = json.Number(strconv.FormatUint(value.Uint64(), 10))
+		case metrics.KindFloat64:
+			m.Kind = "float64"
+			if f := value.Float64(); !math.IsNaN(f) && !math.IsInf(f, 0) {
+				m.Value = json.Number(strconv.FormatFloat(f, 'g', -1, 64))
+			}
+		case metrics.KindFloat64Histogram:
+			m.Kind = "histogram"
+			m.Histogram = NewHistogram(value.Float64Histogram())
+		default:
+			continue
+		}
+
+		out = append(out, m)
+	}
+
+	return out, nil
+}
+
+func CollectJSON() ([]byte, error) {
+	metrics, err := Collect()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(metrics)
+}
+
+func NewHistogram(h *metrics.Float64Histogram) *Histogram {
+	boundaries := make([]*float64, len(h.Buckets))
+	for i, b := range h.Buckets {
+		if math.IsInf(b, 0) {
+			continue
+		}
+		boundaries[i] = &b
+	}
+
+	return &Histogram{
+		Boundaries: boundaries,
+		Counts:     append([]uint64(nil), h.Counts...),
+	}
+}
+
+// Percentile returns the upper boundary of the bucket holding the q-th
+// quantile of h, or its lower boundary when the bucket is unbounded above.
+// The result overestimates the true quantile by at most one bucket width.
+func Percentile(h *metrics.Float64Histogram, q float64) float64 {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen >= rank {
+			return bucketValue(h, i)
+		}
+	}
+	return bucketValue(h, len(h.Counts)-1)
+}
+
+// Max returns the upper boundary of the highest non-empty bucket of h.
+func Max(h *metrics.Float64Histogram) float64 {
+	for i := len(h.Counts) - 1; i >= 0; i-- {
+		if h.Counts[i] > 0 {
+			return bucketValue(h, i)
+		}
+	}
+	return 0
+}
+
+func bucketValue(h *metrics.Float64Histogram, i int) float64 {
+	if upper := h.Buckets[i+1]; !math.IsInf(upper, 0) {
+		return upper
+	}
+	if lower := h.Buckets[i]; !math.IsInf(lower, 0) {
+		return lower
+	}
+	return 0
+}
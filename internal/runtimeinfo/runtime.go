@@ -4,27 +4,44 @@ import (
 	"encoding/json"
 	"runtime"
 	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/cgroup"
 )
 
 type RuntimeInfo struct {
-	GoVersion    string  `json:"go_version"`
-	NumGoroutines int    `json:"num_goroutines"`
-	GOMAXPROCS   int     `json:"gomaxprocs"`
-	NumCPU       int     `json:"num_cpu"`
-	Uptime       float64 `json:"uptime_seconds"`
+	GoVersion     string             `json:"go_version"`
+	NumGoroutines int                `json:"num_goroutines"`
+	GOMAXPROCS    int                `json:"gomaxprocs"`
+	NumCPU        int                `json:"num_cpu"`
+	Uptime        float64            `json:"uptime_seconds"`
+	Cgroup        *cgroup.CgroupInfo `json:"cgroup,omitempty"`
+	Settings      *Settings          `json:"settings"`
 }
 
 func Collect() (*RuntimeInfo, error) {
 	uptime := time.Since(startTime).Seconds()
-	
+	gomaxprocs := runtime.GOMAXPROCS(0)
+
+	cgroupInfo, err := cgroup.Collect(gomaxprocs)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := collectSettings()
+	if err != nil {
+		return nil, err
+	}
+
 	info := &RuntimeInfo{
 		GoVersion:     runtime.Version(),
 		NumGoroutines: runtime.NumGoroutine(),
-		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		GOMAXPROCS:    gomaxprocs,
 		NumCPU:        runtime.NumCPU(),
 		Uptime:        uptime,
+		Cgroup:        cgroupInfo,
+		Settings:      settings,
 	}
-	
+
 	return info, nil
 }
 
@@ -37,4 +54,3 @@ func CollectJSON() ([]byte, error) {
 }
 
 var startTime = time.Now()
-
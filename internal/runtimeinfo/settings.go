@@ -0,0 +1,192 @@
+package runtimeinfo
+
+import (
+	"math"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Aldiwildan77/inspectd/internal/buildinfo"
+	"github.com/Aldiwildan77/inspectd/internal/rtmetrics"
+)
+
+// Setting sources.
+const (
+	SourceDefault = "default"
+	SourceEnv     = "env"
+	SourceCode    = "code"
+	SourceBuild   = "build"
+)
+
+const defaultTraceback = "single"
+
+type Settings struct {
+	MemoryLimit       *int64           `json:"memory_limit_bytes"`
+	MemoryLimitSource string           `json:"memory_limit_source"`
+	GCPercent         int              `json:"gc_percent"`
+	GCPercentSource   string           `json:"gc_percent_source"`
+	Traceback         string           `json:"gotraceback"`
+	TracebackSource   string           `json:"gotraceback_source"`
+	GODEBUG           []GODEBUGSetting `json:"godebug"`
+}
+
+type GODEBUGSetting struct {
+	Name             string  `json:"name"`
+	Value            string  `json:"value"`
+	Source           string  `json:"source"`
+	NonDefaultEvents *uint64 `json:"non_default_events,omitempty"`
+}
+
+func collectSettings() (*Settings, error) {
+	settings := &Settings{
+		GODEBUG: []GODEBUGSetting{},
+	}
+
+	// SetMemoryLimit with a negative value reads the limit without changing it.
+	memoryLimit := debug.SetMemoryLimit(-1)
+	if memoryLimit != math.MaxInt64 {
+		settings.MemoryLimit = &memoryLimit
+	}
+	settings.MemoryLimitSource = source("GOMEMLIMIT", memoryLimit, math.MaxInt64, parseMemoryLimit)
+
+	// GOGC=off is reported as -1.
+	gcPercent := int(int64(rtmetrics.Read("/gc/gogc:percent").Uint64("/gc/gogc:percent")))
+	settings.GCPercent = gcPercent
+	settings.GCPercentSource = source("GOGC", gcPercent, 100, parseGCPercent)
+
+	// The runtime has no getter for the traceback level, so changes made with
+	// debug.SetTraceback cannot be detected.
+	settings.Traceback = defaultTraceback
+	settings.TracebackSource = SourceDefault
+	if env, ok := os.LookupEnv("GOTRACEBACK"); ok && env != "" {
+		settings.Traceback = env
+		settings.TracebackSource = SourceEnv
+	}
+
+	godebug, err := collectGODEBUG()
+	if err != nil {
+		return nil, err
+	}
+	settings.GODEBUG = godebug
+
+	return settings, nil
+}
+
+// source reports whether the current value matches the environment variable,
+// the runtime default, or neither (changed by the program at run time).
+func source[T comparable](name string, current, fallback T, parse func(string) (T, bool)) string {
+	if env, ok := os.LookupEnv(name); ok && env != "" {
+		if value, ok := parse(env); ok {
+			if value == current {
+				return SourceEnv
+			}
+			return SourceCode
+		}
+	}
+	if current == fallback {
+		return SourceDefault
+	}
+	return SourceCode
+}
+
+func parseGCPercent(s string) (int, bool) {
+	if s == "off" {
+		return -1, true
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+// parseMemoryLimit parses GOMEMLIMIT as the runtime does: "off", or a decimal
+// byte count with an optional B, KiB, MiB, GiB or TiB suffix. Fractions,
+// exponents, other units and values beyond math.MaxInt64 are rejected, and so
+// is a sign, which older runtimes do not accept.
+func parseMemoryLimit(s string) (int64, bool) {
+	if s == "off" {
+		return math.MaxInt64, true
+	}
+	units := []struct {
+		suffix     string
+		multiplier uint64
+	}{
+		{"KiB", 1 << 10},
+		{"MiB", 1 << 20},
+		{"GiB", 1 << 30},
+		{"TiB", 1 << 40},
+		{"B", 1},
+	}
+	multiplier := uint64(1)
+	for _, unit := range units {
+		if trimmed, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = trimmed, unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || value > math.MaxInt64/multiplier {
+		return 0, false
+	}
+	return int64(value * multiplier), true
+}
+
+// collectGODEBUG lists every GODEBUG setting that differs from the Go
+// defaults, either through the GODEBUG environment variable or through the
+// defaults compiled into the binary (go.mod godebug lines, //go:debug
+// directives and the go version in go.mod).
+func collectGODEBUG() ([]GODEBUGSetting, error) {
+	values := make(map[string]GODEBUGSetting)
+
+	buildInfo, err := buildinfo.Collect()
+	if err != nil {
+		return nil, err
+	}
+	if buildInfo != nil {
+		for name, value := range parseGODEBUG(buildInfo.Settings["DefaultGODEBUG"]) {
+			values[name] = GODEBUGSetting{Name: name, Value: value, Source: SourceBuild}
+		}
+	}
+	for name, value := range parseGODEBUG(os.Getenv("GODEBUG")) {
+		values[name] = GODEBUGSetting{Name: name, Value: value, Source: SourceEnv}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, "/godebug/non-default-behavior/"+name+":events")
+	}
+	samples := rtmetrics.Read(names...)
+
+	settings := make([]GODEBUGSetting, 0, len(values))
+	for name, setting := range values {
+		metric := "/godebug/non-default-behavior/" + name + ":events"
+		// Settings without a non-default-behavior metric have nothing to count.
+		if samples.Supported(metric) {
+			events := samples.Uint64(metric)
+			setting.NonDefaultEvents = &events
+		}
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Name < settings[j].Name
+	})
+
+	return settings, nil
+}
+
+// parseGODEBUG parses a comma-separated list of name=value pairs; later
+// entries override earlier ones, as in the runtime.
+func parseGODEBUG(s string) map[string]string {
+	values := make(map[string]string)
+	for _, field := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok || name == "" {
+			continue
+		}
+		values[name] = value
+	}
+	return values
+}
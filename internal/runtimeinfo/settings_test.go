@@ -0,0 +1,79 @@
+package runtimeinfo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseMemoryLimit(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   int64
+		wantOK bool
+	}{
+		{"off", math.MaxInt64, true},
+		{"0", 0, true},
+		{"1048576", 1 << 20, true},
+		{"010", 10, true},
+		{"512B", 512, true},
+		{"64KiB", 64 << 10, true},
+		{"512MiB", 512 << 20, true},
+		{"2GiB", 2 << 30, true},
+		{"1TiB", 1 << 40, true},
+		{"9223372036854775807", math.MaxInt64, true},
+		{"8388607TiB", 8388607 << 40, true},
+
+		{"", 0, false},
+		{"B", 0, false},
+		{"GiB", 0, false},
+		{"1.5GiB", 0, false},
+		{"1e9", 0, false},
+		{"+10", 0, false},
+		{"-1", 0, false},
+		{"Inf", 0, false},
+		{"NaN", 0, false},
+		{"0x10", 0, false},
+		{"1_000", 0, false},
+		{" 10", 0, false},
+		{"10 MiB", 0, false},
+		{"10kB", 0, false},
+		{"10KB", 0, false},
+		{"10iB", 0, false},
+		{"10PiB", 0, false},
+		{"10mib", 0, false},
+		{"OFF", 0, false},
+		{"9223372036854775808", 0, false},
+		{"8388608TiB", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := parseMemoryLimit(tt.in)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseMemoryLimit(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		current int64
+		want    string
+	}{
+		{"unset at default", "", math.MaxInt64, SourceDefault},
+		{"unset and changed", "", 1 << 30, SourceCode},
+		{"from the environment", "1GiB", 1 << 30, SourceEnv},
+		{"changed after startup", "1GiB", 2 << 30, SourceCode},
+		{"unparsable at default", "1.5GiB", math.MaxInt64, SourceDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOMEMLIMIT", tt.env)
+			if got := source("GOMEMLIMIT", tt.current, math.MaxInt64, parseMemoryLimit); got != tt.want {
+				t.Errorf("source() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/rtmetrics"
+)
+
+type SchedulerInfo struct {
+	Goroutines        uint64             `json:"goroutines"`
+	Runnable          *uint64            `json:"runnable_goroutines,omitempty"`
+	Running           *uint64            `json:"running_goroutines,omitempty"`
+	Waiting           *uint64            `json:"waiting_goroutines,omitempty"`
+	NotInGo           *uint64            `json:"not_in_go_goroutines,omitempty"`
+	GoroutinesCreated *uint64            `json:"goroutines_created,omitempty"`
+	Threads           *uint64            `json:"threads,omitempty"`
+	GOMAXPROCS        uint64             `json:"gomaxprocs"`
+	MutexWait         float64            `json:"mutex_wait_total_seconds"`
+	Latency           *LatencyStats      `json:"latency"`
+	GOMAXPROCSHistory []GOMAXPROCSChange `json:"gomaxprocs_history"`
+}
+
+// LatencyStats summarizes /sched/latencies:seconds, the time goroutines spent
+// runnable before they got a P. Percentiles are bucket upper bounds.
+type LatencyStats struct {
+	Count     uint64               `json:"count"`
+	P50       float64              `json:"p50_seconds"`
+	P90       float64              `json:"p90_seconds"`
+	P99       float64              `json:"p99_seconds"`
+	Max       float64              `json:"max_seconds"`
+	Histogram *rtmetrics.Histogram `json:"histogram"`
+}
+
+type GOMAXPROCSChange struct {
+	ObservedAt string `json:"observed_at"`
+	GOMAXPROCS uint64 `json:"gomaxprocs"`
+}
+
+// maxHistory bounds the GOMAXPROCS history kept per process.
+const maxHistory = 32
+
+const (
+	goroutinesMetric        = "/sched/goroutines:goroutines"
+	runnableMetric          = "/sched/goroutines/runnable:goroutines"
+	runningMetric           = "/sched/goroutines/running:goroutines"
+	waitingMetric           = "/sched/goroutines/waiting:goroutines"
+	notInGoMetric           = "/sched/goroutines/not-in-go:goroutines"
+	goroutinesCreatedMetric = "/sched/goroutines-created:goroutines"
+	threadsMetric           = "/sched/threads/total:threads"
+	gomaxprocsMetric        = "/sched/gomaxprocs:threads"
+	latenciesMetric         = "/sched/latencies:seconds"
+	mutexWaitMetric         = "/sync/mutex/wait/total:seconds"
+)
+
+// GOMAXPROCS changes are only observed when the scheduler is collected, so the
+// history is recorded per process and reflects the collection timestamps.
+var (
+	historyMu sync.Mutex
+	history   []GOMAXPROCSChange
+)
+
+func Collect() (*SchedulerInfo, error) {
+	samples := rtmetrics.Read(
+		goroutinesMetric,
+		runnableMetric,
+		runningMetric,
+		waitingMetric,
+		notInGoMetric,
+		goroutinesCreatedMetric,
+		threadsMetric,
+		gomaxprocsMetric,
+		latenciesMetric,
+		mutexWaitMetric,
+	)
+
+	// The per-state gauges were added in Go 1.26 and are omitted on older runtimes.
+	gauge := func(name string) *uint64 {
+		if !samples.Supported(name) {
+			return nil
+		}
+		v := samples.Uint64(name)
+		return &v
+	}
+
+	gomaxprocs := samples.Uint64(gomaxprocsMetric)
+
+	info := &SchedulerInfo{
+		Goroutines:        samples.Uint64(goroutinesMetric),
+		Runnable:          gauge(runnableMetric),
+		Running:           gauge(runningMetric),
+		Waiting:           gauge(waitingMetric),
+		NotInGo:           gauge(notInGoMetric),
+		GoroutinesCreated: gauge(goroutinesCreatedMetric),
+		Threads:           gauge(threadsMetric),
+		GOMAXPROCS:        gomaxprocs,
+		MutexWait:         samples.Float64(mutexWaitMetric),
+		GOMAXPROCSHistory: recordGOMAXPROCS(gomaxprocs),
+	}
+
+	if h := samples.Histogram(latenciesMetric); h != nil {
+		info.Latency = latencyStats(h)
+	}
+
+	return info, nil
+}
+
+func CollectJSON() ([]byte, error) {
+	info, err := Collect()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(info)
+}
+
+func latencyStats(h *metrics.Float64Histogram) *LatencyStats {
+	stats := &LatencyStats{
+		P50:       rtmetrics.Percentile(h, 0.50),
+		P90:       rtmetrics.Percentile(h, 0.90),
+		P99:       rtmetrics.Percentile(h, 0.99),
+		Max:       rtmetrics.Max(h),
+		Histogram: rtmetrics.NewHistogram(h),
+	}
+	for _, c := range h.Counts {
+		stats.Count += c
+	}
+	return stats
+}
+
+// recordGOMAXPROCS appends value to the history when it differs from the last
+// observation and returns a copy of the history, oldest first.
+func recordGOMAXPROCS(value uint64) []GOMAXPROCSChange {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
+	if len(history) == 0 || history[len(history)-1].GOMAXPROCS != value {
+		history = append(history, GOMAXPROCSChange{
+			ObservedAt: time.Now().UTC().Format(time.RFC3339Nano),
+			GOMAXPROCS: value,
+		})
+		if len(history) > maxHistory {
+			history = history[len(history)-maxHistory:]
+		}
+	}
+
+	return append([]GOMAXPROCSChange(nil), history...)
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"math"
+	"runtime/metrics"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLatencyStats(t *testing.T) {
+	tests := []struct {
+		name string
+		hist *metrics.Float64Histogram
+		want LatencyStats
+	}{
+		{
+			name: "fixed histogram",
+			hist: &metrics.Float64Histogram{
+				Buckets: []float64{0, 1e-6, 1e-5, 1e-4, math.Inf(1)},
+				Counts:  []uint64{50, 40, 9, 1},
+			},
+			// Ranks 50, 90 and 99 of 100 fall in the first three buckets; the
+			// maximum is in the unbounded bucket, reported by its lower bound.
+			want: LatencyStats{Count: 100, P50: 1e-6, P90: 1e-5, P99: 1e-4, Max: 1e-4},
+		},
+		{
+			name: "single bucket",
+			hist: &metrics.Float64Histogram{
+				Buckets: []float64{0, 1e-6, 1e-5, 1e-4, math.Inf(1)},
+				Counts:  []uint64{0, 0, 7, 0},
+			},
+			want: LatencyStats{Count: 7, P50: 1e-4, P90: 1e-4, P99: 1e-4, Max: 1e-4},
+		},
+		{
+			name: "rank rounds up",
+			hist: &metrics.Float64Histogram{
+				Buckets: []float64{math.Inf(-1), 1e-6, 1e-5, math.Inf(1)},
+				Counts:  []uint64{1, 1, 0},
+			},
+			// p50 of two samples is the first; p90 and p99 round up to the second.
+			want: LatencyStats{Count: 2, P50: 1e-6, P90: 1e-5, P99: 1e-5, Max: 1e-5},
+		},
+		{
+			name: "empty",
+			hist: &metrics.Float64Histogram{
+				Buckets: []float64{0, 1e-6, math.Inf(1)},
+				Counts:  []uint64{0, 0},
+			},
+			want: LatencyStats{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := latencyStats(tt.hist)
+			if got.Count != tt.want.Count || got.P50 != tt.want.P50 || got.P90 != tt.want.P90 || got.P99 != tt.want.P99 || got.Max != tt.want.Max {
+				t.Errorf("latencyStats() = count %d, p50 %g, p90 %g, p99 %g, max %g, want %+v",
+					got.Count, got.P50, got.P90, got.P99, got.Max, tt.want)
+			}
+			if got.Histogram == nil || len(got.Histogram.Counts) != len(tt.hist.Counts) || len(got.Histogram.Boundaries) != len(tt.hist.Buckets) {
+				t.Errorf("Histogram = %+v, want the buckets and counts of the input", got.Histogram)
+			}
+		})
+	}
+}
+
+// resetHistory gives the test an empty GOMAXPROCS history.
+func resetHistory(t *testing.T) {
+	historyMu.Lock()
+	saved := history
+	history = nil
+	historyMu.Unlock()
+	t.Cleanup(func() {
+		historyMu.Lock()
+		history = saved
+		historyMu.Unlock()
+	})
+}
+
+func gomaxprocsValues(changes []GOMAXPROCSChange) []uint64 {
+	values := make([]uint64, len(changes))
+	for i, c := range changes {
+		values[i] = c.GOMAXPROCS
+	}
+	return values
+}
+
+func TestRecordGOMAXPROCS(t *testing.T) {
+	resetHistory(t)
+
+	for _, value := range []uint64{4, 4, 8, 8, 8, 2, 4} {
+		recordGOMAXPROCS(value)
+	}
+	got := recordGOMAXPROCS(4)
+	if want := []uint64{4, 8, 2, 4}; !slices.Equal(gomaxprocsValues(got), want) {
+		t.Errorf("history = %v, want %v", gomaxprocsValues(got), want)
+	}
+	for i := 1; i < len(got); i++ {
+		if mustParse(t, got[i].ObservedAt).Before(mustParse(t, got[i-1].ObservedAt)) {
+			t.Errorf("history is not oldest first: %v", got)
+		}
+	}
+
+	// The returned history is a copy.
+	got[0].GOMAXPROCS = 99
+	if again := recordGOMAXPROCS(4); again[0].GOMAXPROCS != 4 {
+		t.Errorf("history changed through a returned slice: %v", gomaxprocsValues(again))
+	}
+}
+
+func TestRecordGOMAXPROCSCap(t *testing.T) {
+	resetHistory(t)
+
+	var got []GOMAXPROCSChange
+	for i := range maxHistory + 8 {
+		got = recordGOMAXPROCS(uint64(i + 1))
+	}
+	if len(got) != maxHistory {
+		t.Fatalf("history holds %d entries, want %d", len(got), maxHistory)
+	}
+	// The oldest changes are dropped.
+	if first, last := got[0].GOMAXPROCS, got[len(got)-1].GOMAXPROCS; first != 9 || last != maxHistory+8 {
+		t.Errorf("history spans %d to %d, want 9 to %d", first, last, maxHistory+8)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	resetHistory(t)
+
+	info, err := Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Goroutines == 0 || info.GOMAXPROCS == 0 {
+		t.Errorf("Collect() = %+v, want goroutines and GOMAXPROCS", info)
+	}
+	if len(info.GOMAXPROCSHistory) != 1 || info.GOMAXPROCSHistory[0].GOMAXPROCS != info.GOMAXPROCS {
+		t.Errorf("GOMAXPROCSHistory = %+v, want the current value", info.GOMAXPROCSHistory)
+	}
+	if info.Latency == nil {
+		t.Error("Latency = nil, want /sched/latencies:seconds summarized")
+	}
+}
+
+func mustParse(t *testing.T, s string) time.Time {
+	t.Helper()
+	at, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
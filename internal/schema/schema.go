@@ -0,0 +1,236 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Schema is the subset of JSON Schema (draft 2020-12) needed to describe the
+// JSON that inspectd commands write, derived from the Go types that produce it.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// Draft is the JSON Schema dialect of the documents returned by Document.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	numberType     = reflect.TypeFor[json.Number]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// Of returns the schema of the JSON encoding of v's type. Pointers are
+// nullable, fields without omitempty are required and self-referencing
+// types are described once under $defs.
+func Of(v any) *Schema {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	g := &generator{visiting: make(map[reflect.Type]bool), recursive: make(map[reflect.Type]bool), defs: make(map[string]*Schema)}
+	s := g.schema(t)
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s
+}
+
+// Document returns the schema of v's type as a standalone JSON Schema document.
+func Document(title string, v any) *Schema {
+	s := Of(v)
+	s.Schema = Draft
+	s.Title = title
+	return s
+}
+
+// Field returns the schema of the field at path, looking through arrays,
+// nullable values and $refs, or nil if the documented JSON has no such field.
+func (s *Schema) Field(path []string) *Schema {
+	return s.field(s, path)
+}
+
+func (s *Schema) field(root *Schema, path []string) *Schema {
+	if len(path) == 0 {
+		return s
+	}
+
+	switch {
+	case s.Ref != "":
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return nil
+		}
+		return def.field(root, path)
+	case len(s.AnyOf) > 0:
+		for _, alt := range s.AnyOf {
+			if f := alt.field(root, path); f != nil {
+				return f
+			}
+		}
+		return nil
+	case s.Items != nil:
+		return s.Items.field(root, path)
+	case s.Properties != nil:
+		prop, ok := s.Properties[path[0]]
+		if !ok {
+			return nil
+		}
+		return prop.field(root, path[1:])
+	case s.AdditionalProperties != nil:
+		return s.AdditionalProperties.field(root, path[1:])
+	case s.Type == nil:
+		return s // Any value, so any field may exist
+	default:
+		return nil
+	}
+}
+
+type generator struct {
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	defs      map[string]*Schema
+}
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case numberType:
+		return &Schema{Type: "number"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Pointer:
+		return nullable(g.schema(t.Elem()))
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		// Interfaces can hold any value.
+		return &Schema{}
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	name := defName(t)
+	ref := &Schema{Ref: "#/$defs/" + name}
+	if g.visiting[t] {
+		g.recursive[t] = true
+		return ref
+	}
+
+	g.visiting[t] = true
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+	delete(g.visiting, t)
+
+	if g.recursive[t] {
+		g.defs[name] = s
+		return ref
+	}
+	return s
+}
+
+// addFields adds t's encoded fields to s, following encoding/json's rules for
+// names, omitted fields and embedded structs.
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(s, ft)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = g.schema(field.Type)
+		if !hasOption(opts, "omitempty") && !hasOption(opts, "omitzero") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func nullable(s *Schema) *Schema {
+	switch typ := s.Type.(type) {
+	case string:
+		s.Type = []string{typ, "null"}
+		return s
+	case nil:
+		if s.Ref == "" {
+			return s // Already accepts any value
+		}
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+func hasOption(opts, name string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == name {
+			return true
+		}
+	}
+	return false
+}
+
+// defName names a type in $defs by its package and type name, e.g. buildinfo.Module.
+func defName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	if pkg == "" {
+		return t.Name()
+	}
+	return pkg + "." + t.Name()
+}
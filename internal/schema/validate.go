@@ -0,0 +1,115 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Validate checks that the JSON document in data conforms to s: types,
+// nullability, required properties, array items and map values.
+func (s *Schema) Validate(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return fmt.Errorf("failed to decode document: %w", err)
+	}
+	return s.validate(s, value, "$")
+}
+
+func (s *Schema) validate(root *Schema, value any, path string) error {
+	if s.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return fmt.Errorf("%s: unresolved reference %s", path, s.Ref)
+		}
+		return def.validate(root, value, path)
+	}
+
+	if len(s.AnyOf) > 0 {
+		var errs []string
+		for _, alt := range s.AnyOf {
+			err := alt.validate(root, value, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: no alternative matches: %s", path, strings.Join(errs, "; "))
+	}
+
+	if !s.allows(typeOf(value)) {
+		return fmt.Errorf("%s: got %s, want %v", path, typeOf(value), s.Type)
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, field := range v {
+			prop, ok := s.Properties[name]
+			if !ok {
+				prop = s.AdditionalProperties
+			}
+			if prop == nil {
+				continue
+			}
+			if err := prop.validate(root, field, path+"."+name); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, elem := range v {
+				if err := s.Items.validate(root, elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// allows reports whether the schema's type admits a value of the JSON type typ.
+func (s *Schema) allows(typ string) bool {
+	var types []string
+	switch t := s.Type.(type) {
+	case nil:
+		return true
+	case string:
+		types = []string{t}
+	case []string:
+		types = t
+	}
+	for _, t := range types {
+		if t == typ || (t == "number" && typ == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(v.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -2,48 +2,96 @@ package snapshot
 
 import (
 	"encoding/json"
+	"slices"
 	"time"
 
+	"github.com/Aldiwildan77/inspectd/internal/buildinfo"
 	"github.com/Aldiwildan77/inspectd/internal/goroutines"
 	"github.com/Aldiwildan77/inspectd/internal/memory"
+	"github.com/Aldiwildan77/inspectd/internal/osinfo"
 	"github.com/Aldiwildan77/inspectd/internal/runtimeinfo"
+	"github.com/Aldiwildan77/inspectd/internal/scheduler"
 )
 
+// SchemaVersion is reported as schema_version in every snapshot. It is
+// incremented when a field is removed, renamed or changes type; added fields
+// do not change it.
+const SchemaVersion = 2
+
 type Snapshot struct {
-	Timestamp  string                    `json:"timestamp"`
-	Runtime    *runtimeinfo.RuntimeInfo  `json:"runtime"`
-	Memory     *memory.MemoryInfo        `json:"memory"`
-	Goroutines *goroutines.GoroutineInfo `json:"goroutines"`
+	SchemaVersion int                       `json:"schema_version"`
+	Timestamp     string                    `json:"timestamp"`
+	Runtime       *runtimeinfo.RuntimeInfo  `json:"runtime"`
+	Memory        *memory.MemoryInfo        `json:"memory"`
+	Goroutines    *goroutines.GoroutineInfo `json:"goroutines"`
+	Scheduler     *scheduler.SchedulerInfo  `json:"scheduler"`
+	OS            *osinfo.OSInfo            `json:"os,omitempty"`
+	Build         *buildinfo.BuildInfo      `json:"build,omitempty"`
 }
 
 func Collect() (*Snapshot, error) {
-	runtimeInfo, err := runtimeinfo.Collect()
-	if err != nil {
-		return nil, err
+	return CollectSections()
+}
+
+// CollectSections collects only the named top-level sections (runtime, memory,
+// goroutines, scheduler, os, build), or all of them if none are named, so
+// callers that need one section skip the cost of the others. Unknown names are ignored.
+func CollectSections(sections ...string) (*Snapshot, error) {
+	want := func(section string) bool {
+		return len(sections) == 0 || slices.Contains(sections, section)
 	}
 
-	memInfo, err := memory.Collect()
-	if err != nil {
-		return nil, err
+	snapshot := &Snapshot{
+		SchemaVersion: SchemaVersion,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339Nano),
 	}
 
-	goroutineInfo, err := goroutines.Collect()
-	if err != nil {
-		return nil, err
+	var err error
+	if want("runtime") {
+		if snapshot.Runtime, err = runtimeinfo.Collect(); err != nil {
+			return nil, err
+		}
 	}
 
-	snapshot := &Snapshot{
-		Timestamp:  time.Now().UTC().Format(time.RFC3339Nano),
-		Runtime:    runtimeInfo,
-		Memory:     memInfo,
-		Goroutines: goroutineInfo,
+	if want("memory") {
+		if snapshot.Memory, err = memory.Collect(); err != nil {
+			return nil, err
+		}
+	}
+
+	if want("goroutines") {
+		if snapshot.Goroutines, err = goroutines.Collect(); err != nil {
+			return nil, err
+		}
+	}
+
+	if want("scheduler") {
+		if snapshot.Scheduler, err = scheduler.Collect(); err != nil {
+			return nil, err
+		}
+	}
+
+	if want("os") {
+		if snapshot.OS, err = osinfo.Collect(); err != nil {
+			return nil, err
+		}
+	}
+
+	if want("build") {
+		if snapshot.Build, err = buildinfo.Collect(); err != nil {
+			return nil, err
+		}
 	}
 
 	return snapshot, nil
 }
 
 func CollectJSON() ([]byte, error) {
-	snapshot, err := Collect()
+	return CollectSectionsJSON()
+}
+
+func CollectSectionsJSON(sections ...string) ([]byte, error) {
+	snapshot, err := CollectSections(sections...)
 	if err != nil {
 		return nil, err
 	}
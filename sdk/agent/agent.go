@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/attach"
+	"github.com/Aldiwildan77/inspectd/internal/command"
+)
+
+// Agent serves inspectd commands for the current process over a Unix domain socket.
+// The inspectd CLI reaches it with `inspectd --pid <pid> <command>`.
+// No HTTP port is opened; access is limited to users who can open the socket file.
+type Agent struct {
+	socketDir    string
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	listener net.Listener
+	path     string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	once     sync.Once
+	closeErr error
+}
+
+// Option is a function that configures an Agent.
+type Option func(*Agent)
+
+// WithSocketDir overrides the directory the socket is created in.
+// Defaults to $INSPECTD_SOCKET_DIR, or $TMPDIR/inspectd-<uid> when unset.
+// The directory must belong to the current user. The CLI must look in the
+// same directory to find the agent.
+func WithSocketDir(dir string) Option {
+	return func(a *Agent) {
+		a.socketDir = dir
+	}
+}
+
+// WithReadTimeout sets how long a connection may take to send its request (default: 10 seconds).
+func WithReadTimeout(d time.Duration) Option {
+	return func(a *Agent) {
+		a.readTimeout = d
+	}
+}
+
+// WithWriteTimeout sets how long a connection may take to read the response (default: 10 seconds).
+// The timeout starts once the command has run, so it does not limit long captures.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(a *Agent) {
+		a.writeTimeout = d
+	}
+}
+
+// Start creates the per-PID socket and begins serving requests in the background.
+// Call Close to stop serving and remove the socket. Start fails when another
+// agent already serves the process, such as an earlier Start that was not closed.
+func Start(opts ...Option) (*Agent, error) {
+	a := &Agent{
+		socketDir:    attach.SocketDir(),
+		readTimeout:  10 * time.Second,
+		writeTimeout: 10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if err := attach.MakeSocketDir(a.socketDir); err != nil {
+		return nil, err
+	}
+
+	a.path = filepath.Join(a.socketDir, fmt.Sprintf("%d.sock", os.Getpid()))
+
+	// Replacing the socket of another agent would leave it serving nothing and
+	// its Close removing ours.
+	if !claim(a.path) {
+		return nil, fmt.Errorf("an agent is already listening on %s", a.path)
+	}
+	listener, err := listen(a.path)
+	if err != nil {
+		release(a.path)
+		return nil, err
+	}
+	a.listener = listener
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+
+	a.wg.Add(1)
+	go a.serve()
+
+	return a, nil
+}
+
+// listen creates the socket at path, replacing one left behind by an earlier
+// process with the same PID but not one that another process still serves.
+func listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("an agent is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	// The directory is closed to other users, so nobody can connect before Chmod.
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// serving holds the socket paths of the agents started in this process.
+var serving = struct {
+	sync.Mutex
+	paths map[string]bool
+}{paths: make(map[string]bool)}
+
+// claim reserves path for a new agent, reporting false if one already has it.
+func claim(path string) bool {
+	serving.Lock()
+	defer serving.Unlock()
+	if serving.paths[path] {
+		return false
+	}
+	serving.paths[path] = true
+	return true
+}
+
+func release(path string) {
+	serving.Lock()
+	defer serving.Unlock()
+	delete(serving.paths, path)
+}
+
+// SocketPath returns the path of the socket the agent listens on.
+func (a *Agent) SocketPath() string {
+	return a.path
+}
+
+// serve accepts connections until the listener is closed.
+func (a *Agent) serve() {
+	defer a.wg.Done()
+
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			conn.SetReadDeadline(time.Now().Add(a.readTimeout))
+			attach.ServeConn(a.ctx, conn, func(ctx context.Context, name string, args []string) ([]byte, error) {
+				data, err := command.Run(ctx, name, args)
+				// A client that stops reading must not hold up Close.
+				conn.SetWriteDeadline(time.Now().Add(a.writeTimeout))
+				return data, err
+			})
+		}()
+	}
+}
+
+// Close stops accepting connections, cancels captures in progress (CPU profiles,
+// contention windows and traces), waits for in-flight requests to finish and
+// removes the socket file. It is safe to call more than once.
+func (a *Agent) Close() error {
+	a.once.Do(func() {
+		a.closeErr = a.listener.Close()
+		a.cancel()
+		a.wg.Wait()
+		// The listener removed the socket; free the path for a later Start.
+		release(a.path)
+	})
+	return a.closeErr
+}
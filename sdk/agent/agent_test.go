@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/attach"
+)
+
+// useSocketDir points the agent at a private directory for the test; Start creates it.
+func useSocketDir(t *testing.T) {
+	t.Setenv("INSPECTD_SOCKET_DIR", filepath.Join(t.TempDir(), "inspectd"))
+}
+
+func TestAgentServesCommands(t *testing.T) {
+	useSocketDir(t)
+	a, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	data, err := attach.Call(context.Background(), os.Getpid(), "process", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"pid"`) {
+		t.Errorf("process output %s has no pid", data)
+	}
+}
+
+func TestCloseCancelsCaptures(t *testing.T) {
+	useSocketDir(t)
+	a, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := attach.Call(context.Background(), os.Getpid(), "profile", []string{"cpu", "--duration", "1m"})
+		result <- err
+	}()
+	time.Sleep(200 * time.Millisecond) // Let the capture start
+
+	start := time.Now()
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Close took %s, want the capture cancelled", elapsed)
+	}
+	if err := <-result; err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("Call() error = %v, want the cancelled capture", err)
+	}
+	if _, err := os.Stat(a.SocketPath()); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after Close: %v", err)
+	}
+}
+
+func TestStartRejectsForeignSocketDir(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("changing a directory's owner requires root")
+	}
+	dir := t.TempDir()
+	if err := os.Chown(dir, 12345, 12345); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Start(WithSocketDir(dir))
+	if err == nil || !strings.Contains(err.Error(), "owned by uid 12345") {
+		t.Errorf("Start() error = %v, want the directory owner reported", err)
+	}
+}
+
+func TestStartRefusesRunningAgent(t *testing.T) {
+	useSocketDir(t)
+	first, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	if second, err := Start(); err == nil {
+		second.Close()
+		t.Fatal("second Start() succeeded, want the running agent reported")
+	} else if !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("second Start() error = %v, want the running agent reported", err)
+	}
+
+	if _, err := attach.Call(context.Background(), os.Getpid(), "process", nil); err != nil {
+		t.Errorf("first agent stopped serving: %v", err)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := Start()
+	if err != nil {
+		t.Fatalf("Start() after Close: %v", err)
+	}
+	again.Close()
+}
+
+// Another process serving the same path, e.g. a PID namespace sharing the
+// directory, keeps its socket.
+func TestStartRefusesServedSocket(t *testing.T) {
+	useSocketDir(t)
+	path := filepath.Join(os.Getenv("INSPECTD_SOCKET_DIR"), strconv.Itoa(os.Getpid())+".sock")
+	if err := attach.MakeSocketDir(filepath.Dir(path)); err != nil {
+		t.Fatal(err)
+	}
+	other, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	if a, err := Start(); err == nil {
+		a.Close()
+		t.Fatal("Start() succeeded, want the served socket reported")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("served socket removed: %v", err)
+	}
+}
+
+// A socket left behind by a process that exited is replaced.
+func TestStartReplacesStaleSocket(t *testing.T) {
+	useSocketDir(t)
+	path := filepath.Join(os.Getenv("INSPECTD_SOCKET_DIR"), strconv.Itoa(os.Getpid())+".sock")
+	if err := attach.MakeSocketDir(filepath.Dir(path)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	if _, err := attach.Call(context.Background(), os.Getpid(), "process", nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSocketPermissions(t *testing.T) {
+	useSocketDir(t)
+	a, err := Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	info, err := os.Stat(a.SocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+}
@@ -0,0 +1,240 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/sdk/storage"
+	"github.com/Aldiwildan77/inspectd/sdk/types"
+)
+
+// LeakOptions configures goroutine leak detection.
+type LeakOptions struct {
+	// Window is how far back from now to read snapshots (default: 1 hour).
+	Window time.Duration
+
+	// MinSnapshots is the minimum number of snapshots a stack signature must span,
+	// counted from the first one it appears in, before it can be reported (default: 3).
+	MinSnapshots int
+
+	// MinGrowth is the minimum net increase in goroutines between the first and
+	// last snapshot of the window (default: 1). Signatures absent from a snapshot
+	// count as zero goroutines in it.
+	MinGrowth int
+
+	// MinSteadiness is the minimum fraction of consecutive snapshot pairs in which
+	// the count did not decrease, from 0 to 1 (default: 0.75).
+	MinSteadiness float64
+}
+
+// LeakReport lists goroutine stack signatures whose counts grew across a window of snapshots.
+type LeakReport struct {
+	// GeneratedAt is when the report was produced (RFC3339Nano, UTC).
+	GeneratedAt string `json:"generated_at"`
+
+	// WindowStart and WindowEnd are the timestamps of the first and last snapshot analyzed.
+	WindowStart string `json:"window_start"`
+	WindowEnd   string `json:"window_end"`
+
+	// SnapshotsAnalyzed is the number of snapshots that carried goroutine group data.
+	SnapshotsAnalyzed int `json:"snapshots_analyzed"`
+
+	// Suspects is ranked by growth rate, fastest first.
+	Suspects []LeakSuspect `json:"suspects"`
+}
+
+// LeakSuspect is a stack signature whose goroutine count grew steadily.
+type LeakSuspect struct {
+	// Signature identifies the goroutine group (see types.GoroutineGroup).
+	Signature string `json:"signature"`
+
+	// State is the scheduler state of the group.
+	State string `json:"state"`
+
+	// FirstSeen is the timestamp of the first snapshot in the window containing the signature.
+	FirstSeen string `json:"first_seen"`
+
+	// FirstCount and LastCount are the counts in the first and last snapshot of the
+	// window. FirstCount is 0 for signatures that appeared after the window started.
+	FirstCount int `json:"first_count"`
+	LastCount  int `json:"last_count"`
+
+	// GrowthPerMinute is the least-squares slope of the count over time.
+	GrowthPerMinute float64 `json:"growth_per_minute"`
+
+	// Steadiness is the fraction of consecutive snapshot pairs in which the count did not decrease.
+	Steadiness float64 `json:"steadiness"`
+
+	// CreatedBy and Frames are the representative stack from the most recent snapshot.
+	CreatedBy *types.StackFrame  `json:"created_by,omitempty"`
+	Frames    []types.StackFrame `json:"frames"`
+}
+
+// point is the count of one signature in one snapshot.
+type point struct {
+	at    time.Time
+	count int
+}
+
+// DetectGoroutineLeaks reads snapshots from s and reports stack signatures whose goroutine
+// counts grow steadily. Only snapshots that include goroutine groups are considered; collect
+// them with a client created with sdk.WithGoroutineGroups().
+func DetectGoroutineLeaks(ctx context.Context, s storage.Storage, opts *LeakOptions) (*LeakReport, error) {
+	o := LeakOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Window <= 0 {
+		o.Window = time.Hour
+	}
+	if o.MinSnapshots <= 0 {
+		o.MinSnapshots = 3
+	}
+	if o.MinGrowth <= 0 {
+		o.MinGrowth = 1
+	}
+	if o.MinSteadiness <= 0 {
+		o.MinSteadiness = 0.75
+	}
+
+	now := time.Now().UTC()
+	start := now.Add(-o.Window)
+	snapshots, err := s.Query(ctx, &storage.QueryOptions{
+		StartTime: &start,
+		EndTime:   &now,
+		OrderBy:   storage.OrderByTimeAsc,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snapshots: %w", err)
+	}
+
+	// Every series has a point for every snapshot, so growth and slope are
+	// measured over the same window for all signatures.
+	type series struct {
+		group     types.GoroutineGroup
+		points    []point
+		firstSeen int // index of the first point where the signature is present
+	}
+
+	seriesBySignature := make(map[string]*series)
+	order := make([]string, 0)
+	times := make([]time.Time, 0, len(snapshots))
+
+	for _, snapshot := range snapshots {
+		if snapshot.Goroutines == nil || snapshot.Goroutines.Groups == nil {
+			continue
+		}
+		at, err := snapshot.ParseTimestamp()
+		if err != nil {
+			continue
+		}
+
+		// Signatures seen earlier but absent now dropped to zero.
+		present := make(map[string]bool, len(snapshot.Goroutines.Groups))
+		for _, group := range snapshot.Goroutines.Groups {
+			present[group.Signature] = true
+			ser, ok := seriesBySignature[group.Signature]
+			if !ok {
+				// Signatures that appear mid-window had no goroutines in earlier snapshots.
+				ser = &series{points: make([]point, 0, len(snapshots)), firstSeen: len(times)}
+				for _, earlier := range times {
+					ser.points = append(ser.points, point{at: earlier, count: 0})
+				}
+				seriesBySignature[group.Signature] = ser
+				order = append(order, group.Signature)
+			}
+			ser.group = group
+			ser.points = append(ser.points, point{at: at, count: group.Count})
+		}
+		for signature, ser := range seriesBySignature {
+			if !present[signature] {
+				ser.points = append(ser.points, point{at: at, count: 0})
+			}
+		}
+
+		times = append(times, at)
+	}
+
+	if len(times) < 2 {
+		return nil, fmt.Errorf("need at least 2 snapshots with goroutine groups in the last %s, found %d", o.Window, len(times))
+	}
+
+	report := &LeakReport{
+		GeneratedAt:       now.Format(time.RFC3339Nano),
+		WindowStart:       times[0].UTC().Format(time.RFC3339Nano),
+		WindowEnd:         times[len(times)-1].UTC().Format(time.RFC3339Nano),
+		SnapshotsAnalyzed: len(times),
+		Suspects:          make([]LeakSuspect, 0),
+	}
+
+	for _, signature := range order {
+		ser := seriesBySignature[signature]
+		points := ser.points
+		if len(points)-ser.firstSeen < o.MinSnapshots {
+			continue
+		}
+
+		first, last := points[0], points[len(points)-1]
+		if last.count-first.count < o.MinGrowth {
+			continue
+		}
+
+		nonDecreasing := 0
+		for i := 1; i < len(points); i++ {
+			if points[i].count >= points[i-1].count {
+				nonDecreasing++
+			}
+		}
+		steadiness := float64(nonDecreasing) / float64(len(points)-1)
+		if steadiness < o.MinSteadiness {
+			continue
+		}
+
+		slope := growthPerMinute(points)
+		if slope <= 0 {
+			continue
+		}
+
+		report.Suspects = append(report.Suspects, LeakSuspect{
+			Signature:       signature,
+			State:           ser.group.State,
+			FirstSeen:       points[ser.firstSeen].at.UTC().Format(time.RFC3339Nano),
+			FirstCount:      first.count,
+			LastCount:       last.count,
+			GrowthPerMinute: slope,
+			Steadiness:      steadiness,
+			CreatedBy:       ser.group.CreatedBy,
+			Frames:          ser.group.Frames,
+		})
+	}
+
+	sort.SliceStable(report.Suspects, func(i, j int) bool {
+		return report.Suspects[i].GrowthPerMinute > report.Suspects[j].GrowthPerMinute
+	})
+
+	return report, nil
+}
+
+// growthPerMinute fits count = a + b*t by least squares and returns b in goroutines per minute.
+func growthPerMinute(points []point) float64 {
+	origin := points[0].at
+	n := float64(len(points))
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range points {
+		x := p.at.Sub(origin).Minutes()
+		y := float64(p.count)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
@@ -0,0 +1,229 @@
+package analysis
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/sdk/storage"
+	"github.com/Aldiwildan77/inspectd/sdk/types"
+)
+
+// newStorage stores one snapshot per minute, the last one a minute ago. Each
+// element maps signatures to goroutine counts; nil stores a snapshot without
+// goroutine groups.
+func newStorage(t *testing.T, counts []map[string]int) (*storage.MemoryStorage, []time.Time) {
+	t.Helper()
+	s := storage.NewMemoryStorage()
+	now := time.Now().UTC()
+	times := make([]time.Time, len(counts))
+	for i, groups := range counts {
+		times[i] = now.Add(-time.Duration(len(counts)-i) * time.Minute)
+		snapshot := &types.Snapshot{Timestamp: times[i].Format(time.RFC3339Nano)}
+		if groups != nil {
+			snapshot.Goroutines = &types.GoroutineInfo{Groups: []types.GoroutineGroup{}}
+			for signature, count := range groups {
+				snapshot.Goroutines.Groups = append(snapshot.Goroutines.Groups, types.GoroutineGroup{
+					Signature: signature,
+					State:     "chan receive",
+					Count:     count,
+					Frames:    []types.StackFrame{{Function: "main." + signature}},
+				})
+			}
+		}
+		if err := s.Store(context.Background(), snapshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, times
+}
+
+func TestDetectGoroutineLeaks(t *testing.T) {
+	type suspect struct {
+		signature             string
+		firstSeen             int // index into the stored snapshots
+		firstCount, lastCount int
+		growth, steadiness    float64
+	}
+	tests := []struct {
+		name   string
+		counts []map[string]int
+		opts   *LeakOptions
+		want   []suspect
+	}{
+		{
+			name:   "steady growth",
+			counts: []map[string]int{{"a": 1}, {"a": 2}, {"a": 3}, {"a": 4}, {"a": 5}},
+			want:   []suspect{{"a", 0, 1, 5, 1, 1}},
+		},
+		{
+			name:   "flat",
+			counts: []map[string]int{{"a": 5}, {"a": 5}, {"a": 5}, {"a": 5}},
+		},
+		{
+			name:   "shrinking",
+			counts: []map[string]int{{"a": 5}, {"a": 4}, {"a": 3}},
+		},
+		{
+			name:   "below steadiness threshold",
+			counts: []map[string]int{{"a": 1}, {"a": 5}, {"a": 2}, {"a": 6}, {"a": 3}, {"a": 7}},
+		},
+		{
+			name:   "lowered steadiness threshold",
+			counts: []map[string]int{{"a": 1}, {"a": 5}, {"a": 2}, {"a": 6}, {"a": 3}, {"a": 7}},
+			opts:   &LeakOptions{MinSteadiness: 0.6},
+			want:   []suspect{{"a", 0, 1, 7, 0.8, 0.6}},
+		},
+		{
+			name:   "steadiness exactly at threshold",
+			counts: []map[string]int{{"a": 1}, {"a": 2}, {"a": 1}, {"a": 3}, {"a": 4}},
+			want:   []suspect{{"a", 0, 1, 4, 0.7, 0.75}},
+		},
+		{
+			name:   "below minimum growth",
+			counts: []map[string]int{{"a": 1}, {"a": 2}, {"a": 3}},
+			opts:   &LeakOptions{MinGrowth: 3},
+		},
+		{
+			name:   "dropped to zero",
+			counts: []map[string]int{{"a": 3, "b": 1}, {"a": 4, "b": 1}, {"a": 5, "b": 1}, {"b": 1}, {"b": 1}},
+		},
+		{
+			name:   "dropped to zero and came back",
+			counts: []map[string]int{{"a": 1}, {"a": 2}, {"b": 1}, {"a": 3}, {"a": 4}},
+			want:   []suspect{{"a", 0, 1, 4, 0.7, 0.75}},
+		},
+		{
+			name:   "appeared mid-window",
+			counts: []map[string]int{{"b": 1}, {"b": 1}, {"a": 1, "b": 1}, {"a": 2, "b": 1}, {"a": 3, "b": 1}},
+			want:   []suspect{{"a", 2, 0, 3, 0.8, 1}},
+		},
+		{
+			name:   "appeared mid-window at a constant count",
+			counts: []map[string]int{{"b": 1}, {"b": 1}, {"a": 4, "b": 1}, {"a": 4, "b": 1}, {"a": 4, "b": 1}},
+			want:   []suspect{{"a", 2, 0, 4, 1.2, 1}},
+		},
+		{
+			name:   "appeared too recently",
+			counts: []map[string]int{{"b": 1}, {"b": 1}, {"b": 1}, {"a": 1, "b": 1}, {"a": 2, "b": 1}},
+		},
+		{
+			name:   "snapshots without groups are skipped",
+			counts: []map[string]int{{"a": 1}, nil, {"a": 2}, nil, {"a": 3}},
+			want:   []suspect{{"a", 0, 1, 3, 0.5, 1}},
+		},
+		{
+			name:   "ranked by growth",
+			counts: []map[string]int{{"slow": 1, "fast": 1}, {"slow": 2, "fast": 11}, {"slow": 3, "fast": 21}},
+			want:   []suspect{{"fast", 0, 1, 21, 10, 1}, {"slow", 0, 1, 3, 1, 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, times := newStorage(t, tt.counts)
+
+			report, err := DetectGoroutineLeaks(context.Background(), s, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			analyzed := 0
+			for _, c := range tt.counts {
+				if c != nil {
+					analyzed++
+				}
+			}
+			if report.SnapshotsAnalyzed != analyzed {
+				t.Errorf("SnapshotsAnalyzed = %d, want %d", report.SnapshotsAnalyzed, analyzed)
+			}
+			if report.Suspects == nil {
+				t.Error("Suspects is nil, want an empty slice")
+			}
+			if len(report.Suspects) != len(tt.want) {
+				t.Fatalf("got %d suspects %+v, want %d", len(report.Suspects), report.Suspects, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := report.Suspects[i]
+				if got.Signature != want.signature || got.FirstCount != want.firstCount || got.LastCount != want.lastCount {
+					t.Errorf("suspect %d = %s %d->%d, want %s %d->%d", i, got.Signature, got.FirstCount, got.LastCount, want.signature, want.firstCount, want.lastCount)
+				}
+				if wantSeen := times[want.firstSeen].Format(time.RFC3339Nano); got.FirstSeen != wantSeen {
+					t.Errorf("suspect %d FirstSeen = %s, want %s", i, got.FirstSeen, wantSeen)
+				}
+				if math.Abs(got.GrowthPerMinute-want.growth) > 1e-6 {
+					t.Errorf("suspect %d GrowthPerMinute = %v, want %v", i, got.GrowthPerMinute, want.growth)
+				}
+				if math.Abs(got.Steadiness-want.steadiness) > 1e-9 {
+					t.Errorf("suspect %d Steadiness = %v, want %v", i, got.Steadiness, want.steadiness)
+				}
+				if len(got.Frames) != 1 || got.Frames[0].Function != "main."+want.signature {
+					t.Errorf("suspect %d Frames = %+v", i, got.Frames)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectGoroutineLeaksTooFewSnapshots(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts []map[string]int
+	}{
+		{"none", nil},
+		{"one", []map[string]int{{"a": 1}}},
+		{"one with groups", []map[string]int{nil, {"a": 1}, nil}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newStorage(t, tt.counts)
+			_, err := DetectGoroutineLeaks(context.Background(), s, nil)
+			if err == nil || !strings.Contains(err.Error(), "need at least 2 snapshots") {
+				t.Errorf("DetectGoroutineLeaks() error = %v, want too few snapshots", err)
+			}
+		})
+	}
+}
+
+func TestDetectGoroutineLeaksWindow(t *testing.T) {
+	s, _ := newStorage(t, []map[string]int{{"a": 1}, {"a": 2}, {"a": 3}, {"a": 4}})
+
+	// Only the last two snapshots, 2 and 1 minutes ago, fall in the window.
+	report, err := DetectGoroutineLeaks(context.Background(), s, &LeakOptions{Window: 150 * time.Second, MinSnapshots: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.SnapshotsAnalyzed != 2 {
+		t.Errorf("SnapshotsAnalyzed = %d, want 2", report.SnapshotsAnalyzed)
+	}
+	if len(report.Suspects) != 1 || report.Suspects[0].FirstCount != 3 {
+		t.Errorf("Suspects = %+v, want a growing from 3", report.Suspects)
+	}
+}
+
+func TestGrowthPerMinute(t *testing.T) {
+	origin := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration, count int) point { return point{at: origin.Add(d), count: count} }
+
+	tests := []struct {
+		name   string
+		points []point
+		want   float64
+	}{
+		{"single point", []point{at(0, 5)}, 0},
+		{"same time", []point{at(0, 1), at(0, 9)}, 0},
+		{"two points", []point{at(0, 0), at(2*time.Minute, 10)}, 5},
+		{"seconds apart", []point{at(0, 0), at(30*time.Second, 1), at(time.Minute, 2)}, 2},
+		{"uneven spacing", []point{at(0, 0), at(time.Minute, 1), at(4*time.Minute, 4)}, 1},
+		{"decreasing", []point{at(0, 6), at(time.Minute, 4), at(2*time.Minute, 2)}, -2},
+		{"noisy", []point{at(0, 1), at(time.Minute, 3), at(2*time.Minute, 2), at(3*time.Minute, 4)}, 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := growthPerMinute(tt.points); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("growthPerMinute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
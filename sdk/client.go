@@ -4,9 +4,15 @@ import (
 	"context"
 	"time"
 
+	"github.com/Aldiwildan77/inspectd/internal/buildinfo"
 	"github.com/Aldiwildan77/inspectd/internal/goroutines"
 	"github.com/Aldiwildan77/inspectd/internal/memory"
+	"github.com/Aldiwildan77/inspectd/internal/osinfo"
+	"github.com/Aldiwildan77/inspectd/internal/profile"
+	"github.com/Aldiwildan77/inspectd/internal/rtmetrics"
 	"github.com/Aldiwildan77/inspectd/internal/runtimeinfo"
+	"github.com/Aldiwildan77/inspectd/internal/scheduler"
+	"github.com/Aldiwildan77/inspectd/sdk/analysis"
 	"github.com/Aldiwildan77/inspectd/sdk/storage"
 	"github.com/Aldiwildan77/inspectd/sdk/types"
 )
@@ -14,7 +20,9 @@ import (
 // Client provides a high-level API for collecting and storing inspectd snapshots.
 // This is the main entry point for using the inspectd SDK.
 type Client struct {
-	storage storage.Storage
+	storage         storage.Storage
+	goroutineGroups bool
+	heapProfileTop  int
 }
 
 // Option is a function that configures a Client.
@@ -28,6 +36,27 @@ func WithStorage(s storage.Storage) Option {
 	}
 }
 
+// WithGoroutineGroups makes CollectSnapshot include goroutine state counts and
+// identical-stack groups. This takes a full stack dump, which briefly stops the world,
+// but is required for analysis.DetectGoroutineLeaks.
+func WithGoroutineGroups() Option {
+	return func(c *Client) {
+		c.goroutineGroups = true
+	}
+}
+
+// WithHeapProfile makes CollectSnapshot include a heap profile summary with the top
+// allocating functions and sites (by in-use bytes), so stored snapshots record which
+// code is holding the heap over time. top <= 0 uses the default of 20.
+func WithHeapProfile(top int) Option {
+	return func(c *Client) {
+		if top <= 0 {
+			top = profile.DefaultTop
+		}
+		c.heapProfileTop = top
+	}
+}
+
 // NewClient creates a new SDK client with the provided options.
 // At minimum, WithStorage must be provided to configure the storage backend.
 func NewClient(opts ...Option) *Client {
@@ -54,38 +83,81 @@ func (c *Client) CollectSnapshot() (*types.Snapshot, error) {
 	}
 
 	// Collect goroutine information
-	goroutineInfo, err := goroutines.Collect()
+	collectGoroutines := goroutines.Collect
+	if c.goroutineGroups {
+		collectGoroutines = goroutines.CollectGroups
+	}
+	goroutineInfo, err := collectGoroutines()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect scheduler statistics
+	schedulerInfo, err := scheduler.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect process statistics (nil on systems without /proc)
+	osInfo, err := osinfo.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the heap profile summary when enabled
+	var heapProfile *types.HeapProfile
+	if c.heapProfileTop > 0 {
+		heap, err := profile.CollectHeap(profile.HeapOptions{Top: c.heapProfileTop})
+		if err != nil {
+			return nil, err
+		}
+		heapProfile = convertHeapProfile(heap)
+	}
+
+	// Collect build information (nil when built without module support)
+	buildInfo, err := buildinfo.Collect()
 	if err != nil {
 		return nil, err
 	}
 
 	// Convert internal types to SDK types
 	snapshot := &types.Snapshot{
-		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
-		Runtime: &types.RuntimeInfo{
-			GoVersion:     runtimeInfo.GoVersion,
-			NumGoroutines: runtimeInfo.NumGoroutines,
-			GOMAXPROCS:    runtimeInfo.GOMAXPROCS,
-			NumCPU:        runtimeInfo.NumCPU,
-			UptimeSeconds: runtimeInfo.Uptime,
-		},
-		Memory: &types.MemoryInfo{
-			HeapInUseBytes:     memInfo.HeapInUse,
-			HeapAllocatedBytes: memInfo.HeapAllocated,
-			HeapObjects:        memInfo.HeapObjects,
-			TotalAllocBytes:    memInfo.TotalAlloc,
-			GCCycles:           memInfo.GCCycles,
-			LastGCPauseSeconds: memInfo.LastGCPause,
-			GCCPUFraction:      memInfo.GCCPUFraction,
-		},
-		Goroutines: &types.GoroutineInfo{
-			TotalCount: goroutineInfo.TotalCount,
-		},
+		SchemaVersion: types.SchemaVersion,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339Nano),
+		Runtime:       convertRuntime(runtimeInfo),
+		Memory:        convertMemory(memInfo),
+		Goroutines:    convertGoroutines(goroutineInfo),
+		Scheduler:     convertScheduler(schedulerInfo),
+		OS:            convertOS(osInfo),
+		Build:         convertBuild(buildInfo),
+		Heap:          heapProfile,
 	}
 
 	return snapshot, nil
 }
 
+// CollectGoroutines collects a parsed stack dump of every goroutine in the current process.
+// Each goroutine includes its ID, state, wait duration, creator and stack frames;
+// the result also counts goroutines per state and groups identical stacks.
+// Taking the dump briefly stops the world, so avoid calling it on a tight interval.
+func (c *Client) CollectGoroutines() (*types.GoroutineInfo, error) {
+	info, err := goroutines.CollectStacks()
+	if err != nil {
+		return nil, err
+	}
+	return convertGoroutines(info), nil
+}
+
+// CollectMetrics reads every metric exposed by runtime/metrics in the current process.
+// New metrics added by future Go releases are included automatically.
+func (c *Client) CollectMetrics() ([]types.Metric, error) {
+	metrics, err := rtmetrics.Collect()
+	if err != nil {
+		return nil, err
+	}
+	return convertMetrics(metrics), nil
+}
+
 // CollectAndStore collects a snapshot and stores it in the configured storage backend.
 // This is a convenience method that combines CollectSnapshot and Store.
 func (c *Client) CollectAndStore(ctx context.Context) error {
@@ -134,6 +206,12 @@ func (c *Client) QueryRecent(ctx context.Context, limit int) ([]*types.Snapshot,
 	return c.storage.Query(ctx, opts)
 }
 
+// DetectGoroutineLeaks analyzes stored snapshots for goroutine groups whose counts grow steadily.
+// Snapshots must have been collected with WithGoroutineGroups. See analysis.DetectGoroutineLeaks.
+func (c *Client) DetectGoroutineLeaks(ctx context.Context, opts *analysis.LeakOptions) (*analysis.LeakReport, error) {
+	return analysis.DetectGoroutineLeaks(ctx, c.storage, opts)
+}
+
 // Close closes the storage backend and releases resources.
 // Should be called when the client is no longer needed.
 func (c *Client) Close() error {
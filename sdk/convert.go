@@ -0,0 +1,417 @@
+package sdk
+
+import (
+	"github.com/Aldiwildan77/inspectd/internal/buildinfo"
+	"github.com/Aldiwildan77/inspectd/internal/cgroup"
+	"github.com/Aldiwildan77/inspectd/internal/contention"
+	"github.com/Aldiwildan77/inspectd/internal/goroutines"
+	"github.com/Aldiwildan77/inspectd/internal/memory"
+	"github.com/Aldiwildan77/inspectd/internal/osinfo"
+	"github.com/Aldiwildan77/inspectd/internal/profile"
+	"github.com/Aldiwildan77/inspectd/internal/rtmetrics"
+	"github.com/Aldiwildan77/inspectd/internal/runtimeinfo"
+	"github.com/Aldiwildan77/inspectd/internal/scheduler"
+	"github.com/Aldiwildan77/inspectd/sdk/types"
+)
+
+// convertRuntime converts internal runtime information to the SDK type.
+func convertRuntime(info *runtimeinfo.RuntimeInfo) *types.RuntimeInfo {
+	return &types.RuntimeInfo{
+		GoVersion:     info.GoVersion,
+		NumGoroutines: info.NumGoroutines,
+		GOMAXPROCS:    info.GOMAXPROCS,
+		NumCPU:        info.NumCPU,
+		UptimeSeconds: info.Uptime,
+		Cgroup:        convertCgroup(info.Cgroup),
+		Settings:      convertSettings(info.Settings),
+	}
+}
+
+// convertSettings converts internal runtime settings to the SDK type.
+func convertSettings(info *runtimeinfo.Settings) *types.RuntimeSettings {
+	if info == nil {
+		return nil
+	}
+	settings := &types.RuntimeSettings{
+		MemoryLimitBytes:  info.MemoryLimit,
+		MemoryLimitSource: info.MemoryLimitSource,
+		GCPercent:         info.GCPercent,
+		GCPercentSource:   info.GCPercentSource,
+		Traceback:         info.Traceback,
+		TracebackSource:   info.TracebackSource,
+		GODEBUG:           make([]types.GODEBUGSetting, len(info.GODEBUG)),
+	}
+	for i, setting := range info.GODEBUG {
+		settings.GODEBUG[i] = types.GODEBUGSetting{
+			Name:             setting.Name,
+			Value:            setting.Value,
+			Source:           setting.Source,
+			NonDefaultEvents: setting.NonDefaultEvents,
+		}
+	}
+	return settings
+}
+
+// convertCgroup converts internal cgroup information to the SDK type.
+func convertCgroup(info *cgroup.CgroupInfo) *types.CgroupInfo {
+	if info == nil {
+		return nil
+	}
+	return &types.CgroupInfo{
+		Version:                info.Version,
+		CPUQuotaCores:          info.CPUQuotaCores,
+		CPUQuotaMicros:         info.CPUQuotaMicros,
+		CPUPeriodMicros:        info.CPUPeriodMicros,
+		CPUUsageSeconds:        info.CPUUsageSeconds,
+		Periods:                info.Periods,
+		ThrottledPeriods:       info.ThrottledPeriods,
+		ThrottledSeconds:       info.ThrottledSeconds,
+		MemoryLimitBytes:       info.MemoryLimit,
+		MemoryUsageBytes:       info.MemoryUsage,
+		GOMAXPROCSExceedsQuota: info.GOMAXPROCSExceedsQuota,
+	}
+}
+
+// convertMemory converts internal memory information to the SDK type.
+func convertMemory(info *memory.MemoryInfo) *types.MemoryInfo {
+	return &types.MemoryInfo{
+		HeapInUseBytes:     info.HeapInUse,
+		HeapAllocatedBytes: info.HeapAllocated,
+		HeapObjects:        info.HeapObjects,
+		TotalAllocBytes:    info.TotalAlloc,
+		GCCycles:           info.GCCycles,
+		LastGCPauseSeconds: info.LastGCPause,
+		GCCPUFraction:      info.GCCPUFraction,
+		HeapLiveBytes:      info.HeapLive,
+		HeapGoalBytes:      info.HeapGoal,
+		TotalFreeBytes:     info.TotalFree,
+		TotalAllocObjects:  info.TotalAllocObjects,
+		TotalFreeObjects:   info.TotalFreeObjects,
+		TinyAllocObjects:   info.TinyAllocObjects,
+		GCCyclesForced:     info.GCCyclesForced,
+		GCCyclesAutomatic:  info.GCCyclesAutomatic,
+		GCCPUSeconds:       info.GCCPUSeconds,
+		GCScanHeapBytes:    info.GCScanHeap,
+		GCScanStackBytes:   info.GCScanStack,
+		GCScanGlobalsBytes: info.GCScanGlobals,
+		FinalizersQueued:   info.FinalizersQueued,
+		GCPauses:           convertGCPauses(info.GCPauses),
+		Breakdown:          convertBreakdown(info.Breakdown),
+	}
+}
+
+// convertBreakdown converts the internal memory breakdown to the SDK type.
+func convertBreakdown(b *memory.Breakdown) *types.MemoryBreakdown {
+	if b == nil {
+		return nil
+	}
+	return &types.MemoryBreakdown{
+		SysBytes:              b.Sys,
+		RetainedBytes:         b.Retained,
+		HeapObjectsBytes:      b.HeapObjects,
+		HeapUnusedBytes:       b.HeapUnused,
+		HeapFreeBytes:         b.HeapFree,
+		HeapReleasedBytes:     b.HeapReleased,
+		HeapIdleBytes:         b.HeapIdle,
+		StackInUseBytes:       b.StackInUse,
+		OSStacksBytes:         b.OSStacks,
+		MSpanInUseBytes:       b.MSpanInUse,
+		MSpanFreeBytes:        b.MSpanFree,
+		MCacheInUseBytes:      b.MCacheInUse,
+		MCacheFreeBytes:       b.MCacheFree,
+		GCMetadataBytes:       b.GCMetadata,
+		ProfilingBucketsBytes: b.ProfilingBuckets,
+		OtherBytes:            b.Other,
+	}
+}
+
+// convertGCPauses converts internal GC pause statistics to the SDK type.
+func convertGCPauses(stats *memory.GCPauseStats) *types.GCPauseStats {
+	if stats == nil {
+		return nil
+	}
+	out := &types.GCPauseStats{
+		Count:        stats.Count,
+		P50Seconds:   stats.P50,
+		P90Seconds:   stats.P90,
+		P99Seconds:   stats.P99,
+		MaxSeconds:   stats.Max,
+		TotalSeconds: stats.Total,
+		Recent:       make([]types.GCPause, len(stats.Recent)),
+	}
+	for i, p := range stats.Recent {
+		out.Recent[i] = types.GCPause{EndedAt: p.EndedAt, PauseSeconds: p.Pause}
+	}
+	return out
+}
+
+// convertGoroutines converts internal goroutine information to the SDK type.
+func convertGoroutines(info *goroutines.GoroutineInfo) *types.GoroutineInfo {
+	out := &types.GoroutineInfo{
+		TotalCount: info.TotalCount,
+		States:     info.States,
+	}
+	if info.Groups != nil {
+		out.Groups = make([]types.GoroutineGroup, len(info.Groups))
+		for i, g := range info.Groups {
+			out.Groups[i] = types.GoroutineGroup{
+				Signature:      g.Signature,
+				State:          g.State,
+				Count:          g.Count,
+				MaxWaitMinutes: g.MaxWaitMinutes,
+				CreatedBy:      convertFramePtr(g.CreatedBy),
+				Frames:         convertFrames(g.Frames),
+			}
+		}
+	}
+	if info.Goroutines != nil {
+		out.Goroutines = make([]types.Goroutine, len(info.Goroutines))
+		for i, g := range info.Goroutines {
+			out.Goroutines[i] = types.Goroutine{
+				ID:             g.ID,
+				State:          g.State,
+				WaitMinutes:    g.WaitMinutes,
+				LockedToThread: g.LockedToThread,
+				CreatedBy:      convertFramePtr(g.CreatedBy),
+				Frames:         convertFrames(g.Frames),
+			}
+		}
+	}
+	return out
+}
+
+// convertFrames converts internal stack frames to the SDK type.
+func convertFrames(frames []goroutines.Frame) []types.StackFrame {
+	out := make([]types.StackFrame, len(frames))
+	for i, f := range frames {
+		out[i] = types.StackFrame{Function: f.Function, File: f.File, Line: f.Line}
+	}
+	return out
+}
+
+// convertFramePtr converts an optional internal stack frame to the SDK type.
+func convertFramePtr(f *goroutines.Frame) *types.StackFrame {
+	if f == nil {
+		return nil
+	}
+	return &types.StackFrame{Function: f.Function, File: f.File, Line: f.Line}
+}
+
+// convertMetrics converts internal runtime/metrics samples to the SDK type.
+func convertMetrics(metrics []rtmetrics.Metric) []types.Metric {
+	out := make([]types.Metric, len(metrics))
+	for i, m := range metrics {
+		out[i] = types.Metric{
+			Name:        m.Name,
+			Kind:        m.Kind,
+			Unit:        m.Unit,
+			Description: m.Description,
+			Cumulative:  m.Cumulative,
+			Value:       m.Value,
+			Histogram:   convertHistogram(m.Histogram),
+		}
+	}
+	return out
+}
+
+// convertHistogram converts an internal histogram to the SDK type.
+func convertHistogram(h *rtmetrics.Histogram) *types.Histogram {
+	if h == nil {
+		return nil
+	}
+	return &types.Histogram{
+		Boundaries: h.Boundaries,
+		Counts:     h.Counts,
+	}
+}
+
+// convertScheduler converts internal scheduler statistics to the SDK type.
+func convertScheduler(info *scheduler.SchedulerInfo) *types.SchedulerInfo {
+	sched := &types.SchedulerInfo{
+		Goroutines:            info.Goroutines,
+		RunnableGoroutines:    info.Runnable,
+		RunningGoroutines:     info.Running,
+		WaitingGoroutines:     info.Waiting,
+		NotInGoGoroutines:     info.NotInGo,
+		GoroutinesCreated:     info.GoroutinesCreated,
+		Threads:               info.Threads,
+		GOMAXPROCS:            info.GOMAXPROCS,
+		MutexWaitTotalSeconds: info.MutexWait,
+		GOMAXPROCSHistory:     make([]types.GOMAXPROCSChange, len(info.GOMAXPROCSHistory)),
+	}
+	if info.Latency != nil {
+		sched.Latency = &types.SchedulerLatency{
+			Count:      info.Latency.Count,
+			P50Seconds: info.Latency.P50,
+			P90Seconds: info.Latency.P90,
+			P99Seconds: info.Latency.P99,
+			MaxSeconds: info.Latency.Max,
+			Histogram:  convertHistogram(info.Latency.Histogram),
+		}
+	}
+	for i, change := range info.GOMAXPROCSHistory {
+		sched.GOMAXPROCSHistory[i] = types.GOMAXPROCSChange{
+			ObservedAt: change.ObservedAt,
+			GOMAXPROCS: change.GOMAXPROCS,
+		}
+	}
+	return sched
+}
+
+// convertOS converts internal process statistics to the SDK type.
+func convertOS(info *osinfo.OSInfo) *types.OSInfo {
+	if info == nil {
+		return nil
+	}
+	return &types.OSInfo{
+		PID:                    info.PID,
+		RSSBytes:               info.RSS,
+		VSZBytes:               info.VSZ,
+		UserCPUSeconds:         info.UserCPU,
+		SystemCPUSeconds:       info.SystemCPU,
+		OpenFDs:                info.OpenFDs,
+		MaxFDs:                 info.MaxFDs,
+		Threads:                info.Threads,
+		VoluntaryCtxSwitches:   info.VoluntaryCtxSwitches,
+		InvoluntaryCtxSwitches: info.InvoluntaryCtxSwitches,
+	}
+}
+
+// convertBuild converts internal build information to the SDK type.
+func convertBuild(info *buildinfo.BuildInfo) *types.BuildInfo {
+	if info == nil {
+		return nil
+	}
+	build := &types.BuildInfo{
+		GoVersion: info.GoVersion,
+		Path:      info.Path,
+		Main:      convertModule(info.Main),
+		Settings:  info.Settings,
+		Deps:      make([]types.Module, len(info.Deps)),
+	}
+	if info.VCS != nil {
+		build.VCS = &types.VCSInfo{
+			System:   info.VCS.System,
+			Revision: info.VCS.Revision,
+			Time:     info.VCS.Time,
+			Modified: info.VCS.Modified,
+		}
+	}
+	for i, dep := range info.Deps {
+		build.Deps[i] = convertModule(dep)
+	}
+	return build
+}
+
+// convertModule converts an internal module description to the SDK type.
+func convertModule(m buildinfo.Module) types.Module {
+	module := types.Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Sum:     m.Sum,
+	}
+	if m.Replace != nil {
+		replace := convertModule(*m.Replace)
+		module.Replace = &replace
+	}
+	return module
+}
+
+// convertContention converts an internal contention summary to the SDK type.
+func convertContention(info *contention.ContentionInfo) *types.ContentionInfo {
+	return &types.ContentionInfo{
+		DurationSeconds: info.Duration,
+		Mutex:           convertContentionSites(info.Mutex),
+		Block:           convertContentionSites(info.Block),
+	}
+}
+
+// convertContentionSites converts internal contention call sites to the SDK type.
+func convertContentionSites(sites []contention.CallSite) []types.ContentionSite {
+	out := make([]types.ContentionSite, len(sites))
+	for i, site := range sites {
+		out[i] = types.ContentionSite{
+			Function:    site.Function,
+			File:        site.File,
+			Line:        site.Line,
+			Count:       site.Count,
+			WaitSeconds: site.Wait,
+			Frames:      convertProfileFrames(site.Frames),
+		}
+	}
+	return out
+}
+
+// convertProfileFrames converts profile stack frames to the SDK type.
+func convertProfileFrames(frames []profile.Frame) []types.StackFrame {
+	out := make([]types.StackFrame, len(frames))
+	for i, frame := range frames {
+		out[i] = types.StackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}
+	}
+	return out
+}
+
+// convertCPUProfile converts an internal CPU profile summary to the SDK type.
+func convertCPUProfile(info *profile.CPUProfile) *types.CPUProfile {
+	return &types.CPUProfile{
+		DurationSeconds: info.Duration,
+		Samples:         info.Samples,
+		CPUSeconds:      info.CPU,
+		TopFlat:         convertFunctionCosts(info.TopFlat),
+		TopCumulative:   convertFunctionCosts(info.TopCumulative),
+	}
+}
+
+// convertFunctionCosts converts internal per-function CPU costs to the SDK type.
+func convertFunctionCosts(costs []profile.FunctionCost) []types.FunctionCost {
+	out := make([]types.FunctionCost, len(costs))
+	for i, cost := range costs {
+		out[i] = types.FunctionCost{
+			Function:    cost.Function,
+			File:        cost.File,
+			FlatSamples: cost.FlatCount,
+			FlatSeconds: cost.Flat,
+			FlatPercent: cost.FlatPct,
+			CumSamples:  cost.CumCount,
+			CumSeconds:  cost.Cum,
+			CumPercent:  cost.CumPct,
+		}
+	}
+	return out
+}
+
+// convertHeapProfile converts an internal heap profile summary to the SDK type.
+func convertHeapProfile(info *profile.HeapProfile) *types.HeapProfile {
+	return &types.HeapProfile{
+		InUseBytes:   info.InUseBytes,
+		InUseObjects: info.InUseObjects,
+		AllocBytes:   info.AllocBytes,
+		AllocObjects: info.AllocObjects,
+		TopFunctions: convertHeapUsage(info.TopFunctions),
+		TopSites:     convertHeapUsage(info.TopSites),
+	}
+}
+
+// convertHeapUsage converts internal heap usage entries to the SDK type.
+func convertHeapUsage(entries []profile.HeapUsage) []types.HeapUsage {
+	out := make([]types.HeapUsage, len(entries))
+	for i, entry := range entries {
+		out[i] = types.HeapUsage{
+			Function:     entry.Function,
+			File:         entry.File,
+			Line:         entry.Line,
+			InUseBytes:   entry.InUseBytes,
+			InUseObjects: entry.InUseObjects,
+			AllocBytes:   entry.AllocBytes,
+			AllocObjects: entry.AllocObjects,
+		}
+		if entry.Frames != nil {
+			out[i].Frames = convertProfileFrames(entry.Frames)
+		}
+	}
+	return out
+}
@@ -0,0 +1,288 @@
+// Package flightrecorder keeps a rolling window of the Go execution trace in memory
+// and dumps it when something goes wrong, so the trace covers the seconds before an
+// incident rather than starting after it. It requires Go 1.25 or newer.
+package flightrecorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/rtmetrics"
+)
+
+// Recorder continuously records the execution trace into a bounded in-memory window.
+type Recorder struct {
+	window    time.Duration
+	maxBytes  uint64
+	sink      Sink
+	signals   []os.Signal
+	threshold Condition
+	interval  time.Duration
+	cooldown  time.Duration
+	onDump    func(name string, err error)
+
+	tracer   tracer
+	dumpMu   sync.Mutex
+	dumps    atomic.Uint64
+	autoMu   sync.Mutex
+	lastAuto time.Time
+
+	sigCh chan os.Signal
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// tracer is the runtime flight recorder; see tracer_go125.go.
+type tracer interface {
+	Start() error
+	Stop()
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// Option is a function that configures a Recorder.
+type Option func(*Recorder)
+
+// Condition reports whether a threshold trigger should dump the window.
+type Condition func() bool
+
+// WithWindow sets how much recent history the window should hold (default: 10 seconds).
+// The runtime treats this as a lower bound unless the window exceeds the size limit.
+func WithWindow(d time.Duration) Option {
+	return func(r *Recorder) {
+		r.window = d
+	}
+}
+
+// WithMaxBytes caps the size of the window (default: chosen by the runtime).
+// The cap takes precedence over the window duration.
+func WithMaxBytes(n uint64) Option {
+	return func(r *Recorder) {
+		r.maxBytes = n
+	}
+}
+
+// WithSink sets where dumps are written, e.g. FileSink or ObjectStorageSink.
+// Required for signal and threshold triggers and for Dump.
+func WithSink(s Sink) Option {
+	return func(r *Recorder) {
+		r.sink = s
+	}
+}
+
+// WithSignal dumps the window to the sink whenever the process receives one of the signals
+// (e.g., syscall.SIGUSR1).
+func WithSignal(sigs ...os.Signal) Option {
+	return func(r *Recorder) {
+		r.signals = append(r.signals, sigs...)
+	}
+}
+
+// WithThreshold checks cond every interval and dumps the window to the sink when it returns true.
+// See HeapAbove and GoroutinesAbove for common conditions.
+func WithThreshold(interval time.Duration, cond Condition) Option {
+	return func(r *Recorder) {
+		r.interval = interval
+		r.threshold = cond
+	}
+}
+
+// WithCooldown sets the minimum time between dumps fired by signals or thresholds (default: 1 minute),
+// so a condition that stays true does not dump on every check. Dump calls are not limited.
+func WithCooldown(d time.Duration) Option {
+	return func(r *Recorder) {
+		r.cooldown = d
+	}
+}
+
+// WithOnDump registers a callback invoked after every signal or threshold dump with the
+// name written to the sink, or the error that prevented it.
+func WithOnDump(fn func(name string, err error)) Option {
+	return func(r *Recorder) {
+		r.onDump = fn
+	}
+}
+
+// Start begins flight recording and, if configured, watches for signal and threshold triggers.
+// Only one recorder may run per process. Call Close to stop recording.
+func Start(opts ...Option) (*Recorder, error) {
+	r, err := newRecorder(opts)
+	if err != nil {
+		return nil, err
+	}
+	t, err := newTracer(r.window, r.maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.start(t); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// newRecorder applies and validates the options.
+func newRecorder(opts []Option) (*Recorder, error) {
+	r := &Recorder{
+		window:   10 * time.Second,
+		cooldown: time.Minute,
+		stop:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if (len(r.signals) > 0 || r.threshold != nil) && r.sink == nil {
+		return nil, errors.New("signal and threshold triggers require a sink")
+	}
+	if r.threshold != nil && r.interval <= 0 {
+		return nil, errors.New("threshold interval must be positive")
+	}
+	return r, nil
+}
+
+// start begins recording with t and starts the trigger watchers.
+func (r *Recorder) start(t tracer) error {
+	if err := t.Start(); err != nil {
+		return fmt.Errorf("failed to start flight recorder: %w", err)
+	}
+	r.tracer = t
+
+	if len(r.signals) > 0 {
+		r.sigCh = make(chan os.Signal, 1)
+		signal.Notify(r.sigCh, r.signals...)
+		r.wg.Add(1)
+		go r.watchSignals()
+	}
+	if r.threshold != nil {
+		r.wg.Add(1)
+		go r.watchThreshold()
+	}
+	return nil
+}
+
+// WriteTo writes the current window to w as a trace file readable by `go tool trace`.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	r.dumpMu.Lock()
+	defer r.dumpMu.Unlock()
+	return r.tracer.WriteTo(w)
+}
+
+// Dump writes the current window to the sink and returns the name it was stored under.
+// The name holds the time, a sequence number that keeps dumps within the same
+// millisecond apart, and the reason (e.g., "trace-20250101T120000.000Z-000001-timeout.out").
+func (r *Recorder) Dump(ctx context.Context, reason string) (string, error) {
+	if r.sink == nil {
+		return "", errors.New("no sink configured")
+	}
+
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		return "", fmt.Errorf("failed to snapshot flight recorder: %w", err)
+	}
+
+	name := dumpName(time.Now(), r.dumps.Add(1), reason)
+	if err := r.sink.WriteTrace(ctx, name, buf.Bytes()); err != nil {
+		return "", fmt.Errorf("failed to write trace %s: %w", name, err)
+	}
+	return name, nil
+}
+
+// Close stops the triggers and the flight recorder. Dumps in progress complete first.
+func (r *Recorder) Close() error {
+	r.once.Do(func() {
+		close(r.stop)
+		if r.sigCh != nil {
+			signal.Stop(r.sigCh)
+		}
+		r.wg.Wait()
+		r.tracer.Stop()
+	})
+	return nil
+}
+
+func (r *Recorder) watchSignals() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case sig := <-r.sigCh:
+			r.autoDump("signal-" + sig.String())
+		}
+	}
+}
+
+func (r *Recorder) watchThreshold() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if r.threshold() {
+				r.autoDump("threshold")
+			}
+		}
+	}
+}
+
+// autoDump dumps for a signal or threshold trigger, respecting the cooldown.
+func (r *Recorder) autoDump(reason string) {
+	r.autoMu.Lock()
+	now := time.Now()
+	if !r.lastAuto.IsZero() && now.Sub(r.lastAuto) < r.cooldown {
+		r.autoMu.Unlock()
+		return
+	}
+	r.lastAuto = now
+	r.autoMu.Unlock()
+
+	name, err := r.Dump(context.Background(), reason)
+	if r.onDump != nil {
+		r.onDump(name, err)
+	}
+}
+
+// dumpName builds a sortable, filesystem-safe name for the seq-th dump.
+func dumpName(t time.Time, seq uint64, reason string) string {
+	reason = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, reason)
+	name := fmt.Sprintf("trace-%s-%06d", t.UTC().Format("20060102T150405.000Z"), seq)
+	if reason != "" {
+		name += "-" + reason
+	}
+	return name + ".out"
+}
+
+// HeapAbove returns a condition that is true when the live heap exceeds bytes.
+// The live heap is what the last GC cycle marked reachable, so the condition
+// changes once per cycle rather than with every allocation.
+func HeapAbove(bytes uint64) Condition {
+	const metric = "/gc/heap/live:bytes"
+	return func() bool {
+		return rtmetrics.Read(metric).Uint64(metric) > bytes
+	}
+}
+
+// GoroutinesAbove returns a condition that is true when the goroutine count exceeds n.
+func GoroutinesAbove(n uint64) Condition {
+	const metric = "/sched/goroutines:goroutines"
+	return func() bool {
+		return rtmetrics.Read(metric).Uint64(metric) > n
+	}
+}
@@ -0,0 +1,215 @@
+package flightrecorder
+
+import (
+	"context"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTracer stands in for the runtime flight recorder.
+type fakeTracer struct{}
+
+func (fakeTracer) Start() error { return nil }
+func (fakeTracer) Stop()        {}
+func (fakeTracer) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, "go 1.25 trace")
+	return int64(n), err
+}
+
+// memorySink records the names of the dumps written to it.
+type memorySink struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (s *memorySink) WriteTrace(ctx context.Context, name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.names = append(s.names, name)
+	return nil
+}
+
+func (s *memorySink) dumps() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.names...)
+}
+
+// startFake starts a recorder on a fake tracer.
+func startFake(t *testing.T, opts ...Option) *Recorder {
+	t.Helper()
+	r, err := newRecorder(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.start(fakeTracer{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestDumpName(t *testing.T) {
+	at := time.Date(2025, 1, 1, 12, 0, 0, 123456789, time.FixedZone("CET", 3600))
+	tests := []struct {
+		seq    uint64
+		reason string
+		want   string
+	}{
+		{1, "timeout", "trace-20250101T110000.123Z-000001-timeout.out"},
+		{2, "", "trace-20250101T110000.123Z-000002.out"},
+		{3, "signal-user defined signal 1", "trace-20250101T110000.123Z-000003-signal-user-defined-signal-1.out"},
+		{4, "../../etc/passwd", "trace-20250101T110000.123Z-000004-------etc-passwd.out"},
+		{1234567, "slow_request", "trace-20250101T110000.123Z-1234567-slow_request.out"},
+	}
+	for _, tt := range tests {
+		if got := dumpName(at, tt.seq, tt.reason); got != tt.want {
+			t.Errorf("dumpName(%d, %q) = %s, want %s", tt.seq, tt.reason, got, tt.want)
+		}
+	}
+}
+
+// Dumps within the same millisecond and with the same reason must not overwrite each other.
+func TestDumpNamesUnique(t *testing.T) {
+	sink := &memorySink{}
+	r := startFake(t, WithSink(sink))
+
+	for range 20 {
+		if _, err := r.Dump(context.Background(), "burst"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := sink.dumps()
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			t.Fatalf("dump name %s written twice", name)
+		}
+		seen[name] = true
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("dump names %v are not in the order they were written", names)
+	}
+}
+
+func TestDumpRequiresSink(t *testing.T) {
+	r := startFake(t)
+	if _, err := r.Dump(context.Background(), "manual"); err == nil || !strings.Contains(err.Error(), "no sink") {
+		t.Errorf("Dump() error = %v, want the missing sink reported", err)
+	}
+}
+
+func TestAutoDumpCooldown(t *testing.T) {
+	tests := []struct {
+		name     string
+		cooldown time.Duration
+		want     int
+	}{
+		{"within cooldown", time.Hour, 1},
+		{"no cooldown", 0, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &memorySink{}
+			var callbacks atomic.Int32
+			r := startFake(t, WithSink(sink), WithCooldown(tt.cooldown), WithOnDump(func(name string, err error) {
+				if err != nil {
+					t.Errorf("dump failed: %v", err)
+				}
+				callbacks.Add(1)
+			}))
+
+			for range 3 {
+				r.autoDump("threshold")
+			}
+			if got := len(sink.dumps()); got != tt.want {
+				t.Errorf("%d dumps, want %d", got, tt.want)
+			}
+			if got := int(callbacks.Load()); got != tt.want {
+				t.Errorf("%d OnDump calls, want %d", got, tt.want)
+			}
+			// Explicit dumps ignore the cooldown.
+			if _, err := r.Dump(context.Background(), "manual"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestThresholdTrigger(t *testing.T) {
+	sink := &memorySink{}
+	var fire atomic.Bool
+	dumped := make(chan string, 10)
+	startFake(t,
+		WithSink(sink),
+		WithThreshold(5*time.Millisecond, fire.Load),
+		WithOnDump(func(name string, err error) { dumped <- name }),
+	)
+
+	time.Sleep(50 * time.Millisecond)
+	if got := sink.dumps(); len(got) != 0 {
+		t.Fatalf("dumps %v before the condition held", got)
+	}
+
+	fire.Store(true)
+	select {
+	case name := <-dumped:
+		if !strings.HasSuffix(name, "-threshold.out") {
+			t.Errorf("dump name %s, want the threshold reason", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no dump after the condition held")
+	}
+
+	// The default cooldown of a minute holds back further dumps.
+	time.Sleep(50 * time.Millisecond)
+	if got := sink.dumps(); len(got) != 1 {
+		t.Errorf("dumps %v, want one within the cooldown", got)
+	}
+}
+
+func TestStartValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"signal without sink", []Option{WithSignal(fakeSignal{})}, "require a sink"},
+		{"threshold without sink", []Option{WithThreshold(time.Second, func() bool { return false })}, "require a sink"},
+		{"threshold without interval", []Option{WithSink(&memorySink{}), WithThreshold(0, func() bool { return false })}, "interval must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newRecorder(tt.opts); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("newRecorder() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditions(t *testing.T) {
+	runtime.GC() // The live heap is measured by a completed cycle
+	if !HeapAbove(0)() {
+		t.Error("HeapAbove(0) = false, want a non-empty live heap")
+	}
+	if HeapAbove(1 << 50)() {
+		t.Error("HeapAbove(1 PiB) = true")
+	}
+	if !GoroutinesAbove(0)() {
+		t.Error("GoroutinesAbove(0) = false")
+	}
+	if GoroutinesAbove(1 << 30)() {
+		t.Error("GoroutinesAbove(1<<30) = true")
+	}
+}
+
+type fakeSignal struct{}
+
+func (fakeSignal) String() string { return "fake" }
+func (fakeSignal) Signal()        {}
@@ -0,0 +1,42 @@
+package flightrecorder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Aldiwildan77/inspectd/sdk/storage"
+)
+
+// Sink stores dumped trace windows.
+type Sink interface {
+	// WriteTrace stores a trace under name.
+	WriteTrace(ctx context.Context, name string, data []byte) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, name string, data []byte) error
+
+// WriteTrace calls f(ctx, name, data).
+func (f SinkFunc) WriteTrace(ctx context.Context, name string, data []byte) error {
+	return f(ctx, name, data)
+}
+
+// FileSink writes each dump as a file in dir, creating the directory if needed.
+func FileSink(dir string) Sink {
+	return SinkFunc(func(ctx context.Context, name string, data []byte) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create trace directory: %w", err)
+		}
+		return os.WriteFile(filepath.Join(dir, name), data, 0644)
+	})
+}
+
+// ObjectStorageSink uploads each dump to bucket under prefix using any
+// storage.ObjectStorage implementation (S3, GCS, Azure Blob, etc.).
+func ObjectStorageSink(client storage.ObjectStorage, bucket, prefix string) Sink {
+	return SinkFunc(func(ctx context.Context, name string, data []byte) error {
+		return client.PutObject(ctx, bucket, prefix+name, data)
+	})
+}
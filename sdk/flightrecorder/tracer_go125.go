@@ -0,0 +1,15 @@
+//go:build go1.25
+
+package flightrecorder
+
+import (
+	"runtime/trace"
+	"time"
+)
+
+func newTracer(window time.Duration, maxBytes uint64) (tracer, error) {
+	return trace.NewFlightRecorder(trace.FlightRecorderConfig{
+		MinAge:   window,
+		MaxBytes: maxBytes,
+	}), nil
+}
@@ -0,0 +1,12 @@
+//go:build !go1.25
+
+package flightrecorder
+
+import (
+	"errors"
+	"time"
+)
+
+func newTracer(window time.Duration, maxBytes uint64) (tracer, error) {
+	return nil, errors.New("flight recording requires Go 1.25 or newer")
+}
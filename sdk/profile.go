@@ -0,0 +1,116 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Aldiwildan77/inspectd/internal/contention"
+	"github.com/Aldiwildan77/inspectd/internal/profile"
+	"github.com/Aldiwildan77/inspectd/sdk/types"
+)
+
+// ContentionOptions configures CollectContention.
+type ContentionOptions struct {
+	// Duration is how long mutex and block profiling stay enabled (default: 5 seconds, maximum: 5 minutes).
+	Duration time.Duration
+
+	// Top is the number of call sites reported per profile (default: 10).
+	Top int
+}
+
+// CollectContention enables mutex and block profiling for a bounded window and returns the
+// call sites that waited the longest during it. The call blocks for opts.Duration unless ctx
+// is cancelled first. Profiling is opt-in because it adds overhead to every lock and blocking
+// operation while enabled; afterwards the mutex profile fraction is restored and the block
+// profile rate is reset to 0. Only one capture may run at a time per process.
+func (c *Client) CollectContention(ctx context.Context, opts *ContentionOptions) (*types.ContentionInfo, error) {
+	if opts == nil {
+		opts = &ContentionOptions{}
+	}
+	info, err := contention.Collect(ctx, contention.Options{
+		Duration: opts.Duration,
+		Top:      opts.Top,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return convertContention(info), nil
+}
+
+// CPUProfileOptions configures CollectCPUProfile.
+type CPUProfileOptions struct {
+	// Duration is how long the CPU profiler runs (default: 10 seconds, maximum: 5 minutes).
+	Duration time.Duration
+
+	// Top is the number of functions reported in each ranking (default: 20).
+	Top int
+
+	// Output, if set, receives the raw gzipped pprof profile for use with `go tool pprof`.
+	Output io.Writer
+}
+
+// CollectCPUProfile runs the CPU profiler for a bounded duration and returns the functions
+// that used the most CPU, ranked both by flat time (in the function itself) and by cumulative
+// time (including callees). The call blocks for opts.Duration unless ctx is cancelled first.
+// It fails if a CPU profile is already running in the process (e.g., via net/http/pprof).
+func (c *Client) CollectCPUProfile(ctx context.Context, opts *CPUProfileOptions) (*types.CPUProfile, error) {
+	if opts == nil {
+		opts = &CPUProfileOptions{}
+	}
+	result, err := profile.CollectCPU(ctx, profile.CPUOptions{
+		Duration: opts.Duration,
+		Top:      opts.Top,
+		Raw:      opts.Output != nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Output != nil {
+		if _, err := opts.Output.Write(result.PProf); err != nil {
+			return nil, fmt.Errorf("failed to write profile: %w", err)
+		}
+	}
+	return convertCPUProfile(result), nil
+}
+
+// HeapProfileOptions configures CollectHeapProfile.
+type HeapProfileOptions struct {
+	// Top is the number of functions and allocation sites reported (default: 20).
+	Top int
+
+	// SortBy ranks entries by "inuse" (default) or "alloc" (total allocated) bytes.
+	SortBy string
+
+	// GC runs a garbage collection first so in-use values are current rather than
+	// as of the last completed GC cycle.
+	GC bool
+
+	// Output, if set, receives the raw gzipped pprof profile for use with `go tool pprof`.
+	Output io.Writer
+}
+
+// CollectHeapProfile reads the heap profile and returns in-use and allocated bytes and objects
+// aggregated by allocating function and by allocation site (function and line).
+// The runtime samples allocations (one per 512 KiB by default), so values are estimates.
+func (c *Client) CollectHeapProfile(opts *HeapProfileOptions) (*types.HeapProfile, error) {
+	if opts == nil {
+		opts = &HeapProfileOptions{}
+	}
+	result, err := profile.CollectHeap(profile.HeapOptions{
+		Top:    opts.Top,
+		SortBy: opts.SortBy,
+		GC:     opts.GC,
+		Raw:    opts.Output != nil,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Output != nil {
+		if _, err := opts.Output.Write(result.PProf); err != nil {
+			return nil, fmt.Errorf("failed to write profile: %w", err)
+		}
+	}
+	return convertHeapProfile(result), nil
+}
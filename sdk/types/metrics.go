@@ -0,0 +1,38 @@
+package types
+
+import "encoding/json"
+
+// Metric is a single runtime/metrics sample with its metadata.
+// Exactly one of Value or Histogram is set, depending on Kind.
+type Metric struct {
+	// Name is the runtime/metrics name (e.g., "/gc/heap/goal:bytes").
+	Name string `json:"name"`
+
+	// Kind is "uint64", "float64" or "histogram".
+	Kind string `json:"kind"`
+
+	// Unit is the unit suffix of the name (e.g., "bytes", "seconds").
+	Unit string `json:"unit"`
+
+	// Description is the runtime's description of the metric.
+	Description string `json:"description"`
+
+	// Cumulative reports whether the value only ever increases.
+	Cumulative bool `json:"cumulative"`
+
+	// Value is the sample for uint64 and float64 metrics.
+	Value json.Number `json:"value,omitempty"`
+
+	// Histogram is the sample for histogram metrics.
+	Histogram *Histogram `json:"histogram,omitempty"`
+}
+
+// Histogram is a runtime/metrics histogram.
+type Histogram struct {
+	// Boundaries has len(Counts)+1 entries; Counts[i] covers [Boundaries[i], Boundaries[i+1]).
+	// A nil boundary is infinite (negative when first, positive when last).
+	Boundaries []*float64 `json:"boundaries"`
+
+	// Counts is the number of samples in each bucket.
+	Counts []uint64 `json:"counts"`
+}
@@ -0,0 +1,128 @@
+package types
+
+// ContentionInfo summarizes mutex and blocking contention recorded during a profiling window.
+type ContentionInfo struct {
+	// DurationSeconds is the length of the profiling window.
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// Mutex lists the call sites that held contended mutexes the longest, by total wait caused.
+	Mutex []ContentionSite `json:"mutex"`
+
+	// Block lists the call sites that spent the most time blocked (locks, channels, select, WaitGroup).
+	Block []ContentionSite `json:"block"`
+}
+
+// ContentionSite aggregates contention at one call site, the first frame outside the runtime and sync packages.
+type ContentionSite struct {
+	// Function is the fully qualified function name of the call site.
+	Function string `json:"function"`
+
+	// File is the source file of the call site.
+	File string `json:"file"`
+
+	// Line is the line number of the call site.
+	Line int `json:"line"`
+
+	// Count is the number of contention events.
+	Count int64 `json:"count"`
+
+	// WaitSeconds is the total time goroutines waited.
+	WaitSeconds float64 `json:"wait_seconds"`
+
+	// Frames is the stack that contributed the most wait time, leaf first.
+	Frames []StackFrame `json:"frames"`
+}
+
+// CPUProfile summarizes a CPU profile as the functions that used the most CPU.
+type CPUProfile struct {
+	// DurationSeconds is how long the profiler ran.
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// Samples is the number of CPU samples taken (100 per CPU-second).
+	Samples int64 `json:"samples"`
+
+	// CPUSeconds is the total CPU time sampled across all threads.
+	CPUSeconds float64 `json:"cpu_seconds"`
+
+	// TopFlat ranks functions by CPU time spent in the function itself.
+	TopFlat []FunctionCost `json:"top_flat"`
+
+	// TopCumulative ranks functions by CPU time spent in the function and its callees.
+	TopCumulative []FunctionCost `json:"top_cumulative"`
+}
+
+// FunctionCost is the CPU time attributed to one function. Inlined functions are reported separately.
+type FunctionCost struct {
+	// Function is the fully qualified function name.
+	Function string `json:"function"`
+
+	// File is the source file defining the function.
+	File string `json:"file"`
+
+	// FlatSamples is the number of samples where the function was executing.
+	FlatSamples int64 `json:"flat_samples"`
+
+	// FlatSeconds is the CPU time spent in the function itself.
+	FlatSeconds float64 `json:"flat_seconds"`
+
+	// FlatPercent is FlatSeconds as a percentage of the profile's total CPU time.
+	FlatPercent float64 `json:"flat_percent"`
+
+	// CumSamples is the number of samples where the function was on the stack.
+	CumSamples int64 `json:"cum_samples"`
+
+	// CumSeconds is the CPU time spent in the function and everything it called.
+	CumSeconds float64 `json:"cum_seconds"`
+
+	// CumPercent is CumSeconds as a percentage of the profile's total CPU time.
+	CumPercent float64 `json:"cum_percent"`
+}
+
+// HeapProfile summarizes the heap profile by allocating function and allocation site.
+// Values are the runtime's estimates scaled up from sampled allocations.
+type HeapProfile struct {
+	// InUseBytes is the memory held by live objects as of the last GC.
+	InUseBytes int64 `json:"inuse_bytes"`
+
+	// InUseObjects is the number of live objects as of the last GC.
+	InUseObjects int64 `json:"inuse_objects"`
+
+	// AllocBytes is the total memory allocated since the process started.
+	AllocBytes int64 `json:"alloc_bytes"`
+
+	// AllocObjects is the total number of objects allocated since the process started.
+	AllocObjects int64 `json:"alloc_objects"`
+
+	// TopFunctions ranks the functions that performed the allocations.
+	TopFunctions []HeapUsage `json:"top_functions"`
+
+	// TopSites ranks allocation sites (function and line).
+	TopSites []HeapUsage `json:"top_sites"`
+}
+
+// HeapUsage is the heap memory attributed to one allocating function or allocation site.
+type HeapUsage struct {
+	// Function is the fully qualified name of the allocating function.
+	Function string `json:"function"`
+
+	// File is the source file of the allocation.
+	File string `json:"file"`
+
+	// Line is the line of the allocation; zero for per-function entries.
+	Line int `json:"line,omitempty"`
+
+	// InUseBytes is the memory still held by objects allocated here.
+	InUseBytes int64 `json:"inuse_bytes"`
+
+	// InUseObjects is the number of live objects allocated here.
+	InUseObjects int64 `json:"inuse_objects"`
+
+	// AllocBytes is the total memory allocated here.
+	AllocBytes int64 `json:"alloc_bytes"`
+
+	// AllocObjects is the total number of objects allocated here.
+	AllocObjects int64 `json:"alloc_objects"`
+
+	// Frames is the call stack contributing the most to an allocation site, leaf first.
+	Frames []StackFrame `json:"frames,omitempty"`
+}
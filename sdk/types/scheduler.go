@@ -0,0 +1,66 @@
+package types
+
+// SchedulerInfo describes how goroutines are being scheduled onto Ps.
+// A growing runnable count or scheduling latency means goroutines are waiting for CPU.
+type SchedulerInfo struct {
+	// Goroutines is the number of live goroutines.
+	Goroutines uint64 `json:"goroutines"`
+
+	// RunnableGoroutines is the number of goroutines ready to run but waiting for a P.
+	// The per-state counts are nil on Go versions before 1.26.
+	RunnableGoroutines *uint64 `json:"runnable_goroutines,omitempty"`
+
+	// RunningGoroutines is the number of goroutines currently running on a P.
+	RunningGoroutines *uint64 `json:"running_goroutines,omitempty"`
+
+	// WaitingGoroutines is the number of goroutines blocked on a resource (channels, locks, I/O).
+	WaitingGoroutines *uint64 `json:"waiting_goroutines,omitempty"`
+
+	// NotInGoGoroutines is the number of goroutines in a syscall or cgo call.
+	NotInGoGoroutines *uint64 `json:"not_in_go_goroutines,omitempty"`
+
+	// GoroutinesCreated is the cumulative number of goroutines created.
+	GoroutinesCreated *uint64 `json:"goroutines_created,omitempty"`
+
+	// Threads is the number of OS threads owned by the runtime.
+	Threads *uint64 `json:"threads,omitempty"`
+
+	// GOMAXPROCS is the current number of Ps.
+	GOMAXPROCS uint64 `json:"gomaxprocs"`
+
+	// MutexWaitTotalSeconds is the approximate cumulative time goroutines spent blocked on
+	// sync.Mutex, sync.RWMutex or runtime-internal locks. It is always collected, unlike CollectContention.
+	MutexWaitTotalSeconds float64 `json:"mutex_wait_total_seconds"`
+
+	// Latency summarizes the time goroutines spent runnable before running.
+	Latency *SchedulerLatency `json:"latency"`
+
+	// GOMAXPROCSHistory lists GOMAXPROCS values observed by this process's collections, oldest first (up to 32).
+	GOMAXPROCSHistory []GOMAXPROCSChange `json:"gomaxprocs_history"`
+}
+
+// SchedulerLatency summarizes the /sched/latencies:seconds histogram since process start.
+type SchedulerLatency struct {
+	// Count is the number of scheduling events in the histogram.
+	Count uint64 `json:"count"`
+
+	// P50Seconds, P90Seconds and P99Seconds are percentiles, reported as the upper bound of their bucket.
+	P50Seconds float64 `json:"p50_seconds"`
+	P90Seconds float64 `json:"p90_seconds"`
+	P99Seconds float64 `json:"p99_seconds"`
+
+	// MaxSeconds is the upper bound of the highest non-empty bucket.
+	MaxSeconds float64 `json:"max_seconds"`
+
+	// Histogram is the raw latency histogram.
+	Histogram *Histogram `json:"histogram"`
+}
+
+// GOMAXPROCSChange records a GOMAXPROCS value and when it was first observed.
+type GOMAXPROCSChange struct {
+	// ObservedAt is when the value was first observed (RFC3339Nano, UTC).
+	ObservedAt string `json:"observed_at"`
+
+	// GOMAXPROCS is the observed value.
+	GOMAXPROCS uint64 `json:"gomaxprocs"`
+}
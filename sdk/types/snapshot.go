@@ -5,9 +5,18 @@ import (
 	"time"
 )
 
+// SchemaVersion is the version of the Snapshot JSON layout written by this SDK.
+// It is incremented when a field is removed, renamed or changes type; added
+// fields do not change it.
+const SchemaVersion = 2
+
 // Snapshot represents a complete runtime snapshot at a point in time.
 // This is the main data structure that can be stored using the SDK.
 type Snapshot struct {
+	// SchemaVersion is the SchemaVersion of the SDK that collected the snapshot.
+	// Snapshots stored before the field existed decode with 0.
+	SchemaVersion int `json:"schema_version"`
+
 	// Timestamp is the UTC time when this snapshot was collected.
 	// Format: RFC3339Nano (e.g., "2024-01-01T12:00:00.123456789Z")
 	Timestamp string `json:"timestamp"`
@@ -20,6 +29,19 @@ type Snapshot struct {
 
 	// Goroutines contains goroutine count information.
 	Goroutines *GoroutineInfo `json:"goroutines"`
+
+	// Scheduler contains run queue and scheduling latency statistics.
+	Scheduler *SchedulerInfo `json:"scheduler"`
+
+	// OS contains process-level statistics from /proc; nil on systems without /proc.
+	OS *OSInfo `json:"os,omitempty"`
+
+	// Build describes the binary that produced the snapshot; nil when built without module support.
+	Build *BuildInfo `json:"build,omitempty"`
+
+	// Heap summarizes the heap profile by allocating function and site; only set
+	// when the client was created with WithHeapProfile.
+	Heap *HeapProfile `json:"heap,omitempty"`
 }
 
 // RuntimeInfo contains Go runtime metrics.
@@ -38,6 +60,91 @@ type RuntimeInfo struct {
 
 	// UptimeSeconds is the process uptime in seconds.
 	UptimeSeconds float64 `json:"uptime_seconds"`
+
+	// Cgroup contains container CPU and memory limits; nil outside a cgroup.
+	Cgroup *CgroupInfo `json:"cgroup,omitempty"`
+
+	// Settings contains the effective GC, memory limit, traceback and GODEBUG settings.
+	Settings *RuntimeSettings `json:"settings"`
+}
+
+// RuntimeSettings describes the effective runtime configuration and where each value came from.
+// Sources are "default", "env" (environment variable), "code" (changed by the program at run time)
+// or, for GODEBUG, "build" (defaults compiled into the binary from go.mod or //go:debug directives).
+type RuntimeSettings struct {
+	// MemoryLimitBytes is the soft memory limit (GOMEMLIMIT); nil when no limit is set.
+	MemoryLimitBytes *int64 `json:"memory_limit_bytes"`
+
+	// MemoryLimitSource is where the memory limit came from.
+	MemoryLimitSource string `json:"memory_limit_source"`
+
+	// GCPercent is the GC target percentage (GOGC); -1 when the GC is off.
+	GCPercent int `json:"gc_percent"`
+
+	// GCPercentSource is where the GC percent came from.
+	GCPercentSource string `json:"gc_percent_source"`
+
+	// Traceback is the GOTRACEBACK level. Changes made with debug.SetTraceback are not visible.
+	Traceback string `json:"gotraceback"`
+
+	// TracebackSource is "env" or "default".
+	TracebackSource string `json:"gotraceback_source"`
+
+	// GODEBUG lists every GODEBUG setting that differs from the Go defaults.
+	GODEBUG []GODEBUGSetting `json:"godebug"`
+}
+
+// GODEBUGSetting is a single non-default GODEBUG setting.
+type GODEBUGSetting struct {
+	// Name is the setting name (e.g., "panicnil").
+	Name string `json:"name"`
+
+	// Value is the effective value.
+	Value string `json:"value"`
+
+	// Source is "env" or "build".
+	Source string `json:"source"`
+
+	// NonDefaultEvents counts how often the program relied on the non-default behavior,
+	// from /godebug/non-default-behavior/<name>:events; nil for settings without such a metric.
+	NonDefaultEvents *uint64 `json:"non_default_events,omitempty"`
+}
+
+// CgroupInfo describes the CPU and memory limits of the cgroup (v1 or v2) the process runs in.
+type CgroupInfo struct {
+	// Version is the cgroup version (1 or 2).
+	Version int `json:"version"`
+
+	// CPUQuotaCores is the CPU quota in cores (quota / period); nil when unlimited.
+	CPUQuotaCores *float64 `json:"cpu_quota_cores"`
+
+	// CPUQuotaMicros is the CFS quota per period in microseconds; -1 when unlimited.
+	CPUQuotaMicros int64 `json:"cpu_quota_us"`
+
+	// CPUPeriodMicros is the CFS period in microseconds.
+	CPUPeriodMicros uint64 `json:"cpu_period_us"`
+
+	// CPUUsageSeconds is the total CPU time consumed by the cgroup.
+	CPUUsageSeconds float64 `json:"cpu_usage_seconds"`
+
+	// Periods is the number of CFS enforcement periods that have elapsed.
+	Periods uint64 `json:"nr_periods"`
+
+	// ThrottledPeriods is the number of periods in which the cgroup was throttled.
+	ThrottledPeriods uint64 `json:"nr_throttled"`
+
+	// ThrottledSeconds is the total time the cgroup was throttled.
+	ThrottledSeconds float64 `json:"throttled_seconds"`
+
+	// MemoryLimitBytes is the memory limit; nil when unlimited.
+	MemoryLimitBytes *uint64 `json:"memory_limit_bytes"`
+
+	// MemoryUsageBytes is the current memory usage of the cgroup.
+	MemoryUsageBytes uint64 `json:"memory_usage_bytes"`
+
+	// GOMAXPROCSExceedsQuota reports whether GOMAXPROCS is larger than the CPU quota
+	// rounded up, which leads to CPU throttling and tail latency.
+	GOMAXPROCSExceedsQuota bool `json:"gomaxprocs_exceeds_quota"`
 }
 
 // MemoryInfo contains memory usage and GC statistics.
@@ -62,12 +169,284 @@ type MemoryInfo struct {
 
 	// GCCPUFraction is the fraction of CPU time spent in GC.
 	GCCPUFraction float64 `json:"gc_cpu_fraction"`
+
+	// HeapLiveBytes is the heap memory occupied by live objects as of the last GC.
+	HeapLiveBytes uint64 `json:"heap_live_bytes"`
+
+	// HeapGoalBytes is the heap size target for the end of the current GC cycle.
+	HeapGoalBytes uint64 `json:"heap_goal_bytes"`
+
+	// TotalFreeBytes is the cumulative bytes freed by the garbage collector.
+	TotalFreeBytes uint64 `json:"total_free_bytes"`
+
+	// TotalAllocObjects is the cumulative count of heap allocations.
+	TotalAllocObjects uint64 `json:"total_alloc_objects"`
+
+	// TotalFreeObjects is the cumulative count of heap objects freed.
+	TotalFreeObjects uint64 `json:"total_free_objects"`
+
+	// TinyAllocObjects is the count of small allocations packed into shared blocks.
+	TinyAllocObjects uint64 `json:"tiny_alloc_objects"`
+
+	// GCCyclesForced is the number of GC cycles triggered by the application (e.g. runtime.GC).
+	GCCyclesForced uint64 `json:"gc_cycles_forced"`
+
+	// GCCyclesAutomatic is the number of GC cycles triggered by the runtime.
+	GCCyclesAutomatic uint64 `json:"gc_cycles_automatic"`
+
+	// GCCPUSeconds is the estimated CPU time spent in GC since the process started.
+	GCCPUSeconds float64 `json:"gc_cpu_seconds"`
+
+	// GCScanHeapBytes is the scannable heap memory as of the last GC.
+	GCScanHeapBytes uint64 `json:"gc_scan_heap_bytes"`
+
+	// GCScanStackBytes is the stack memory scanned during the last GC.
+	GCScanStackBytes uint64 `json:"gc_scan_stack_bytes"`
+
+	// GCScanGlobalsBytes is the scannable global variable memory.
+	GCScanGlobalsBytes uint64 `json:"gc_scan_globals_bytes"`
+
+	// FinalizersQueued is the number of finalizers queued to run.
+	FinalizersQueued uint64 `json:"finalizers_queued"`
+
+	// GCPauses summarizes recent GC stop-the-world pauses.
+	GCPauses *GCPauseStats `json:"gc_pauses"`
+
+	// Breakdown accounts for all memory mapped by the Go runtime.
+	Breakdown *MemoryBreakdown `json:"breakdown"`
+}
+
+// MemoryBreakdown accounts for all memory mapped by the Go runtime, by class.
+// The classes from HeapObjectsBytes to OtherBytes sum to SysBytes.
+type MemoryBreakdown struct {
+	// SysBytes is the total memory mapped by the Go runtime (MemStats.Sys).
+	SysBytes uint64 `json:"sys_bytes"`
+
+	// RetainedBytes is SysBytes minus memory released to the OS;
+	// it is an upper bound on the Go runtime's share of RSS.
+	RetainedBytes uint64 `json:"retained_bytes"`
+
+	// HeapObjectsBytes is memory occupied by live objects and not-yet-swept dead objects.
+	HeapObjectsBytes uint64 `json:"heap_objects_bytes"`
+
+	// HeapUnusedBytes is memory in in-use heap spans not occupied by objects (fragmentation).
+	HeapUnusedBytes uint64 `json:"heap_unused_bytes"`
+
+	// HeapFreeBytes is idle heap memory that could be returned to the OS but has not been.
+	HeapFreeBytes uint64 `json:"heap_free_bytes"`
+
+	// HeapReleasedBytes is idle heap memory that has been returned to the OS.
+	HeapReleasedBytes uint64 `json:"heap_released_bytes"`
+
+	// HeapIdleBytes is HeapFreeBytes plus HeapReleasedBytes (MemStats.HeapIdle).
+	HeapIdleBytes uint64 `json:"heap_idle_bytes"`
+
+	// StackInUseBytes is memory used for goroutine stacks (MemStats.StackInuse).
+	StackInUseBytes uint64 `json:"stack_in_use_bytes"`
+
+	// OSStacksBytes is stack memory allocated by the OS for threads (e.g., cgo).
+	OSStacksBytes uint64 `json:"os_stacks_bytes"`
+
+	// MSpanInUseBytes and MSpanFreeBytes are memory for mspan structures.
+	MSpanInUseBytes uint64 `json:"mspan_in_use_bytes"`
+	MSpanFreeBytes  uint64 `json:"mspan_free_bytes"`
+
+	// MCacheInUseBytes and MCacheFreeBytes are memory for per-P mcache structures.
+	MCacheInUseBytes uint64 `json:"mcache_in_use_bytes"`
+	MCacheFreeBytes  uint64 `json:"mcache_free_bytes"`
+
+	// GCMetadataBytes is memory for GC and other runtime metadata (MemStats.GCSys).
+	GCMetadataBytes uint64 `json:"gc_metadata_bytes"`
+
+	// ProfilingBucketsBytes is memory for profiling stack trace hash tables.
+	ProfilingBucketsBytes uint64 `json:"profiling_buckets_bytes"`
+
+	// OtherBytes is other memory used by the runtime (MemStats.OtherSys).
+	OtherBytes uint64 `json:"other_bytes"`
+}
+
+// GCPauseStats summarizes the stop-the-world pauses of recent GC cycles.
+type GCPauseStats struct {
+	// Count is the number of pauses the percentiles cover (up to the last 256 GC cycles).
+	Count int `json:"count"`
+
+	// P50Seconds, P90Seconds and P99Seconds are nearest-rank pause percentiles.
+	P50Seconds float64 `json:"p50_seconds"`
+	P90Seconds float64 `json:"p90_seconds"`
+	P99Seconds float64 `json:"p99_seconds"`
+
+	// MaxSeconds is the longest pause in the history.
+	MaxSeconds float64 `json:"max_seconds"`
+
+	// TotalSeconds is the cumulative pause time since the process started.
+	TotalSeconds float64 `json:"total_seconds"`
+
+	// Recent lists the most recent pauses (up to 32), newest first.
+	Recent []GCPause `json:"recent"`
+}
+
+// GCPause is a single GC stop-the-world pause.
+type GCPause struct {
+	// EndedAt is when the pause ended (RFC3339Nano, UTC).
+	EndedAt string `json:"ended_at"`
+
+	// PauseSeconds is the pause duration.
+	PauseSeconds float64 `json:"pause_seconds"`
 }
 
 // GoroutineInfo contains goroutine count information.
 type GoroutineInfo struct {
 	// TotalCount is the total number of goroutines.
 	TotalCount int `json:"total_count"`
+
+	// States counts goroutines by scheduler state (e.g., "chan receive": 39000).
+	States map[string]int `json:"states,omitempty"`
+
+	// Groups clusters goroutines with the same state and identical stack, largest first.
+	Groups []GoroutineGroup `json:"groups,omitempty"`
+
+	// Goroutines is the parsed stack dump of every goroutine.
+	// Only populated by Client.CollectGoroutines; omitted from regular snapshots.
+	Goroutines []Goroutine `json:"goroutines,omitempty"`
+}
+
+// GoroutineGroup is a set of goroutines in the same state with an identical stack.
+type GoroutineGroup struct {
+	// Signature identifies the state and stack; it is stable across snapshots of the same binary.
+	Signature string `json:"signature"`
+
+	// State is the scheduler state shared by the group.
+	State string `json:"state"`
+
+	// Count is the number of goroutines in the group.
+	Count int `json:"count"`
+
+	// MaxWaitMinutes is the longest wait reported for any goroutine in the group.
+	MaxWaitMinutes int `json:"max_wait_minutes"`
+
+	// CreatedBy is the go statement that started the goroutines.
+	CreatedBy *StackFrame `json:"created_by,omitempty"`
+
+	// Frames is the representative stack, innermost frame first.
+	Frames []StackFrame `json:"frames"`
+}
+
+// Goroutine is a single goroutine parsed from a runtime stack dump.
+type Goroutine struct {
+	// ID is the goroutine ID.
+	ID int64 `json:"id"`
+
+	// State is the scheduler state (e.g., "running", "chan receive", "IO wait").
+	State string `json:"state"`
+
+	// WaitMinutes is how long the goroutine has been blocked.
+	// The runtime only reports waits of one minute or more; shorter waits are 0.
+	WaitMinutes int `json:"wait_minutes"`
+
+	// LockedToThread reports whether the goroutine is locked to an OS thread.
+	LockedToThread bool `json:"locked_to_thread"`
+
+	// CreatedBy is the go statement that started the goroutine (nil for the main goroutine).
+	CreatedBy *StackFrame `json:"created_by,omitempty"`
+
+	// Frames is the call stack, innermost frame first.
+	Frames []StackFrame `json:"frames"`
+}
+
+// StackFrame is a single function call in a goroutine stack.
+type StackFrame struct {
+	// Function is the fully qualified function name (e.g., "main.(*Server).handle").
+	Function string `json:"function"`
+
+	// File is the absolute source file path.
+	File string `json:"file"`
+
+	// Line is the line number within File.
+	Line int `json:"line"`
+}
+
+// OSInfo contains process-level statistics read from the Linux /proc filesystem.
+type OSInfo struct {
+	// PID is the process ID.
+	PID int `json:"pid"`
+
+	// RSSBytes is the resident set size.
+	RSSBytes uint64 `json:"rss_bytes"`
+
+	// VSZBytes is the virtual memory size.
+	VSZBytes uint64 `json:"vsz_bytes"`
+
+	// UserCPUSeconds is the CPU time spent in user mode.
+	UserCPUSeconds float64 `json:"user_cpu_seconds"`
+
+	// SystemCPUSeconds is the CPU time spent in kernel mode.
+	SystemCPUSeconds float64 `json:"system_cpu_seconds"`
+
+	// OpenFDs is the number of open file descriptors; nil when they cannot be listed.
+	OpenFDs *int `json:"open_fds"`
+
+	// MaxFDs is the soft RLIMIT_NOFILE; nil when unlimited.
+	MaxFDs *uint64 `json:"max_fds"`
+
+	// Threads is the number of OS threads.
+	Threads int `json:"threads"`
+
+	// VoluntaryCtxSwitches counts context switches where the process gave up the CPU (e.g., blocking I/O).
+	VoluntaryCtxSwitches uint64 `json:"voluntary_ctxt_switches"`
+
+	// InvoluntaryCtxSwitches counts context switches forced by the kernel scheduler.
+	InvoluntaryCtxSwitches uint64 `json:"involuntary_ctxt_switches"`
+}
+
+// BuildInfo describes how the binary was built, as embedded by the Go toolchain.
+type BuildInfo struct {
+	// GoVersion is the Go toolchain version used to build the binary.
+	GoVersion string `json:"go_version"`
+
+	// Path is the import path of the main package.
+	Path string `json:"path"`
+
+	// Main is the module containing the main package.
+	Main Module `json:"main"`
+
+	// VCS holds version control details; nil when not built from a VCS checkout.
+	VCS *VCSInfo `json:"vcs,omitempty"`
+
+	// Settings contains the build settings (e.g., CGO_ENABLED, GOARCH, -tags, -ldflags).
+	Settings map[string]string `json:"settings"`
+
+	// Deps lists every dependency module linked into the binary.
+	Deps []Module `json:"deps"`
+}
+
+// Module describes a Go module linked into the binary.
+type Module struct {
+	// Path is the module path.
+	Path string `json:"path"`
+
+	// Version is the module version ("(devel)" for the main module in local builds).
+	Version string `json:"version"`
+
+	// Sum is the go.sum checksum of the module.
+	Sum string `json:"sum,omitempty"`
+
+	// Replace is the module that replaced this one via a replace directive.
+	Replace *Module `json:"replace,omitempty"`
+}
+
+// VCSInfo contains the version control state the binary was built from.
+type VCSInfo struct {
+	// System is the version control system (e.g., "git").
+	System string `json:"system"`
+
+	// Revision is the commit the binary was built from.
+	Revision string `json:"revision"`
+
+	// Time is the commit time in RFC3339 format.
+	Time string `json:"time"`
+
+	// Modified reports whether the working tree had uncommitted changes.
+	Modified bool `json:"modified"`
 }
 
 // ParseTimestamp parses the timestamp string and returns a time.Time.